@@ -0,0 +1,400 @@
+package commands
+
+import (
+	"aggreGATOR/internal/database"
+	"aggreGATOR/internal/rssfeed"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// feedFetchTimeout bounds how long a worker spends on a single feed
+	feedFetchTimeout = 30 * time.Second
+	// feedLeaseDuration is how long a claimed feed stays reserved for this
+	// process; it must outlast a fetch so other aggregators don't take it over
+	feedLeaseDuration = 5 * time.Minute
+	// backoffBase and backoffMax bound the delay before a failing feed is
+	// retried; the delay doubles with every consecutive failure
+	backoffBase = time.Minute
+	backoffMax  = 24 * time.Hour
+	// shutdownGracePeriod is how long in-flight fetches may keep running
+	// after SIGINT/SIGTERM before they are cancelled
+	shutdownGracePeriod = 10 * time.Second
+)
+
+type aggOptions struct {
+	workers int
+	// maxFailures is the number of consecutive failures after which a feed
+	// is disabled; zero keeps retrying forever
+	maxFailures int
+	// out receives progress and error messages for each feed
+	out io.Writer
+}
+
+// aggStats counts the outcome of every feed fetched during an agg run
+type aggStats struct {
+	mu          sync.Mutex
+	started     time.Time
+	fetched     int
+	notModified int
+	failed      int
+	skipped     int
+	cancelled   int
+	posts       int
+}
+
+func (st *aggStats) record(saved int, err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.posts += saved
+	switch {
+	case err == nil:
+		st.fetched++
+	case errors.Is(err, rssfeed.ErrNotModified):
+		st.fetched++
+		st.notModified++
+	case errors.Is(err, context.Canceled):
+		st.cancelled++
+	default:
+		st.failed++
+	}
+}
+
+// skip counts a feed that was left alone because another process holds it
+func (st *aggStats) skip() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.skipped++
+}
+
+func (st *aggStats) summary() string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return fmt.Sprintf("Fetched %d feed(s) (%d not modified), %d failed, %d skipped, %d cancelled; saved %d new post(s) in %s",
+		st.fetched, st.notModified, st.failed, st.skipped, st.cancelled, st.posts, time.Since(st.started).Round(time.Second))
+}
+
+func handlerAgg(s *State, cmd Command) error {
+	workers := cmd.Int("workers")
+	maxFailures := cmd.Int("max-failures")
+	once := cmd.Bool("once")
+	all := cmd.Bool("all")
+	feedURL := cmd.String("feed")
+	oneShot := once || all || feedURL != ""
+	if len(cmd.Args) < 1 && !oneShot {
+		return fmt.Errorf("agg requires a time_between_reqs argument (e.g. 1m, 10s) or one of --once, --all, --feed")
+	}
+	if workers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+	if maxFailures < 0 {
+		return fmt.Errorf("--max-failures must not be negative")
+	}
+	opts := aggOptions{workers: workers, maxFailures: maxFailures, out: os.Stdout}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Restore default signal handling once shutdown starts, so a second
+	// Ctrl-C exits immediately
+	context.AfterFunc(ctx, stop)
+	stats := &aggStats{started: time.Now()}
+
+	if oneShot {
+		var feeds []database.Feed
+		var err error
+		switch {
+		case feedURL != "":
+			feed, err := s.Db.GetFeedByUrl(ctx, feedURL)
+			if err != nil {
+				return fmt.Errorf("could not find feed with url %s: %v", feedURL, err)
+			}
+			feeds = append(feeds, feed)
+		case all:
+			feeds, err = s.Db.GetAllFeeds(ctx)
+		default:
+			feeds, err = s.Db.GetDueFeeds(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get feeds to fetch: %v", err)
+		}
+		fmt.Printf("Fetching %d feed(s) with %d worker(s)\n", len(feeds), workers)
+		fetchFeeds(ctx, s, opts, stats, feeds, false)
+		fmt.Println(stats.summary())
+		if stats.failed > 0 {
+			return fmt.Errorf("%d feed(s) failed", stats.failed)
+		}
+		return ctx.Err()
+	}
+
+	timeBetweenRequests, err := time.ParseDuration(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid duration: %v", err)
+	}
+	fmt.Printf("Collecting feeds every %s with %d worker(s)\n", timeBetweenRequests, workers)
+	ticker := time.NewTicker(timeBetweenRequests)
+	defer ticker.Stop()
+	for {
+		scrapeFeeds(ctx, s, opts, stats)
+		select {
+		case <-ctx.Done():
+			fmt.Println(stats.summary())
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrapeFeeds claims up to opts.workers of the least recently fetched due
+// feeds and fetches them in parallel. Claims are leased, so several agg
+// processes can share the feeds table without fetching the same feed twice.
+func scrapeFeeds(ctx context.Context, s *State, opts aggOptions, stats *aggStats) {
+	feeds, err := s.Db.ClaimFeedsToFetch(ctx, database.ClaimFeedsToFetchParams{
+		LeaseSeconds: int32(feedLeaseDuration / time.Second),
+		BatchSize:    int32(opts.workers),
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Fprintln(opts.out, "Error claiming feeds to fetch:", err)
+		}
+		return
+	}
+	if len(feeds) == 0 {
+		fmt.Fprintln(opts.out, "No feeds to fetch")
+		return
+	}
+	fetchFeeds(ctx, s, opts, stats, feeds, true)
+}
+
+// fetchFeeds fetches feeds with a pool of opts.workers workers. Feeds that
+// are not already claimed are leased one at a time as a worker picks them up,
+// and skipped if another aggregator holds them.
+//
+// Once ctx is cancelled no further feeds are started; fetches already in
+// flight get shutdownGracePeriod to finish before they are cancelled too.
+func fetchFeeds(ctx context.Context, s *State, opts aggOptions, stats *aggStats, feeds []database.Feed, claimed bool) {
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+	stopGrace := context.AfterFunc(ctx, func() {
+		fmt.Fprintf(opts.out, "Shutting down, waiting up to %s for in-flight fetches\n", shutdownGracePeriod)
+		time.AfterFunc(shutdownGracePeriod, cancelWork)
+	})
+	defer stopGrace()
+
+	jobs := make(chan database.Feed)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feed := range jobs {
+				if !claimed {
+					leased, err := s.Db.ClaimFeed(workCtx, database.ClaimFeedParams{
+						ID:           feed.ID,
+						LeaseSeconds: int32(feedLeaseDuration / time.Second),
+					})
+					if err != nil {
+						if !errors.Is(err, sql.ErrNoRows) {
+							fmt.Fprintf(opts.out, "Error claiming feed %s: %v\n", feed.Name, err)
+						}
+						stats.skip()
+						continue
+					}
+					feed = leased
+				}
+				fetchCtx, cancel := context.WithTimeout(workCtx, feedFetchTimeout)
+				saved, err := scrapeFeed(fetchCtx, s, feed, opts.out)
+				cancel()
+				if workCtx.Err() != nil {
+					err = context.Canceled
+				}
+				stats.record(saved, err)
+				finishFeed(workCtx, s, feed, err, opts)
+			}
+		}()
+	}
+	for i, feed := range feeds {
+		select {
+		case jobs <- feed:
+		case <-ctx.Done():
+			// Hand unstarted feeds back so the next run picks them up first
+			if claimed {
+				for _, f := range feeds[i:] {
+					releaseFeed(s, f, opts.out)
+				}
+			}
+			close(jobs)
+			wg.Wait()
+			return
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// finishFeed records the outcome of a fetch and releases the feed's lease.
+// A cancelled fetch is neither marked fetched nor counted as a failure.
+func finishFeed(ctx context.Context, s *State, feed database.Feed, fetchErr error, opts aggOptions) {
+	if errors.Is(fetchErr, context.Canceled) {
+		releaseFeed(s, feed, opts.out)
+		return
+	}
+	recordFeedResult(ctx, s, feed, fetchErr, opts)
+	// Releases the lease and moves the feed to the back of the rotation
+	if err := s.Db.MarkFeedFetched(ctx, feed.ID); err != nil {
+		fmt.Fprintf(opts.out, "Error marking feed %s fetched: %v\n", feed.Name, err)
+	}
+}
+
+// releaseFeed gives up the lease on a feed that was claimed but not fetched.
+// It runs after shutdown has begun, so it uses its own short-lived context.
+func releaseFeed(s *State, feed database.Feed, out io.Writer) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Db.ReleaseFeedLease(ctx, feed.ID); err != nil {
+		fmt.Fprintf(out, "Error releasing feed %s: %v\n", feed.Name, err)
+	}
+}
+
+// recordFeedResult resets a feed's failure state after a successful fetch,
+// or schedules its next attempt with exponential backoff after a failure
+func recordFeedResult(ctx context.Context, s *State, feed database.Feed, fetchErr error, opts aggOptions) {
+	if fetchErr == nil || errors.Is(fetchErr, rssfeed.ErrNotModified) {
+		if err := s.Db.RecordFeedSuccess(ctx, feed.ID); err != nil {
+			fmt.Fprintf(opts.out, "Error recording success for feed %s: %v\n", feed.Name, err)
+		}
+		return
+	}
+	failures := int(feed.FailureCount) + 1
+	params := database.RecordFeedFailureParams{
+		ID:             feed.ID,
+		FailureCount:   int32(failures),
+		LastError:      sql.NullString{String: fetchErr.Error(), Valid: true},
+		BackoffSeconds: int32(backoffDelay(failures) / time.Second),
+		Disable:        opts.maxFailures > 0 && failures >= opts.maxFailures,
+	}
+	if params.Disable {
+		fmt.Fprintf(opts.out, "Disabling feed %s after %d consecutive failures\n", feed.Name, failures)
+	}
+	if err := s.Db.RecordFeedFailure(ctx, params); err != nil {
+		fmt.Fprintf(opts.out, "Error recording failure for feed %s: %v\n", feed.Name, err)
+	}
+}
+
+// backoffDelay returns how long to wait before retrying a feed that has
+// failed the given number of consecutive times
+func backoffDelay(failures int) time.Duration {
+	delay := backoffBase
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= backoffMax {
+			return backoffMax
+		}
+	}
+	return delay
+}
+
+// scrapeFeed fetches a feed and stores its new posts, returning how many were
+// saved. It returns rssfeed.ErrNotModified when the feed hasn't changed, and
+// any other error only when the feed itself could not be fetched or parsed.
+func scrapeFeed(ctx context.Context, s *State, feed database.Feed, out io.Writer) (int, error) {
+	cache := rssfeed.CacheValidators{ETag: feed.Etag.String, LastModified: feed.LastModified.String}
+	rss, validators, err := rssfeed.FetchFeed(ctx, feed.Url, cache)
+	if errors.Is(err, rssfeed.ErrNotModified) {
+		fmt.Fprintf(out, "Feed: %s (not modified)\n", feed.Name)
+		return 0, err
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Error fetching feed %s: %v\n", feed.Name, err)
+		return 0, err
+	}
+	// The channel title names the feed for followers when whoever added it
+	// didn't give it a real name
+	if title := strings.TrimSpace(rss.Channel.Title); title != "" && title != feed.ChannelTitle.String {
+		err = s.Db.UpdateFeedChannelTitle(ctx, database.UpdateFeedChannelTitleParams{
+			ID:           feed.ID,
+			ChannelTitle: sql.NullString{String: title, Valid: true},
+		})
+		if err != nil {
+			fmt.Fprintf(out, "Error saving channel title for feed %s: %v\n", feed.Name, err)
+		}
+	}
+	loc, err := s.Cfg.Location()
+	if err != nil {
+		fmt.Fprintf(out, "Invalid timezone %q, using UTC: %v\n", s.Cfg.Timezone, err)
+		loc = time.UTC
+	}
+	fmt.Fprintf(out, "Feed: %s\n", feed.Name)
+	saved, failed := 0, 0
+	for _, item := range rss.Channel.Items {
+		if ctx.Err() != nil {
+			return saved, ctx.Err()
+		}
+		id := uuid.New()
+		now := time.Now().UTC()
+		url := item.Link
+		if url == "" {
+			url = item.Guid
+		}
+		// Posts without a parseable date are stored with a NULL published_at
+		// and sorted by when they were first seen instead. The columns have no
+		// zone, so both times are stored in UTC to compare correctly.
+		published, ok := item.Published(loc)
+		publishedAt := sql.NullTime{Time: published.UTC(), Valid: ok}
+		params := database.CreatePostParams{
+			ID:          id,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Title:       item.Title,
+			Url:         url,
+			Description: sql.NullString{String: item.Description, Valid: item.Description != ""},
+			PublishedAt: publishedAt,
+			FeedID:      feed.ID,
+		}
+		_, err := s.Db.CreatePost(ctx, params)
+		if err != nil {
+			if isUniqueViolation(err) {
+				continue
+			}
+			fmt.Fprintf(out, "Error saving post '%s': %v\n", item.Title, err)
+			failed++
+			continue
+		}
+		saved++
+	}
+	// Validators are only saved once every post is stored; otherwise the
+	// next fetch would get "not modified" and never retry the failed posts
+	if failed == 0 && validators != cache {
+		err = s.Db.UpdateFeedCacheValidators(ctx, database.UpdateFeedCacheValidatorsParams{
+			ID:           feed.ID,
+			Etag:         sql.NullString{String: validators.ETag, Valid: validators.ETag != ""},
+			LastModified: sql.NullString{String: validators.LastModified, Valid: validators.LastModified != ""},
+		})
+		if err != nil {
+			fmt.Fprintf(out, "Error saving cache validators for feed %s: %v\n", feed.Name, err)
+		}
+	}
+	// The posts that were saved still count, but the feed is recorded as
+	// failed so it is retried with backoff
+	if failed > 0 {
+		return saved, fmt.Errorf("%d post(s) failed to save", failed)
+	}
+	return saved, nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint error
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"aggreGATOR/internal/database"
+	"aggreGATOR/internal/rssfeed"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type postRecord struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	FeedID      uuid.UUID  `json:"feed_id" table:"-"`
+	FeedName    string     `json:"feed_name"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at" table:"-"`
+	ReadAt      *time.Time `json:"read_at"`
+	Description *string    `json:"description" table:"-"`
+}
+
+// browse command: prints recent unread posts from the feeds the current user
+// follows, with an optional limit and filters
+func handlerBrowse(s *State, cmd Command, user database.User) error {
+	feedFilter := cmd.String("feed")
+	since := cmd.String("since")
+	until := cmd.String("until")
+	offset := cmd.Int("offset")
+	all := cmd.Bool("all")
+	markRead := cmd.Bool("mark-read")
+	tagFilter := cmd.String("tag")
+	limit, err := cmd.intArg(0, "limit", 2)
+	if err != nil {
+		return err
+	}
+	if offset < 0 {
+		return fmt.Errorf("--offset must not be negative")
+	}
+	params := database.GetPostsForUserParams{
+		UserID:      user.ID,
+		IncludeRead: all,
+		PageSize:    int32(limit),
+		PageOffset:  int32(offset),
+	}
+	if feedFilter != "" {
+		feedID, err := resolveFollowedFeed(s, user, feedFilter)
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feedID, Valid: true}
+	}
+	if tagFilter != "" {
+		tag, err := normalizeTag(tagFilter)
+		if err != nil {
+			return err
+		}
+		if err := checkTagUsed(s, user, tag); err != nil {
+			return err
+		}
+		params.Tag = sql.NullString{String: tag, Valid: true}
+	}
+	loc, err := s.Cfg.Location()
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %v", s.Cfg.Timezone, err)
+	}
+	if since != "" {
+		t, err := parseTimeBound(since, loc)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %v", err)
+		}
+		params.Since = sql.NullTime{Time: t, Valid: true}
+	}
+	if until != "" {
+		t, err := parseTimeBound(until, loc)
+		if err != nil {
+			return fmt.Errorf("invalid --until: %v", err)
+		}
+		params.Until = sql.NullTime{Time: t, Valid: true}
+	}
+	posts, err := s.Db.GetPostsForUser(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("failed to get posts: %v", err)
+	}
+	if err := printPosts(s, posts); err != nil {
+		return err
+	}
+	if markRead {
+		for _, post := range posts {
+			if post.ReadAt.Valid {
+				continue
+			}
+			err := s.Db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+				UserID: user.ID,
+				PostID: post.ID,
+				ReadAt: time.Now().UTC(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to mark post read: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// printPosts prints browse results in the state's output format
+func printPosts(s *State, posts []database.GetPostsForUserRow) error {
+	if s.Output.structured() {
+		records := make([]postRecord, len(posts))
+		for i, p := range posts {
+			records[i] = postRecord{
+				ID:          p.ID,
+				Title:       p.Title,
+				URL:         p.Url,
+				FeedID:      p.FeedID,
+				FeedName:    p.FeedName,
+				PublishedAt: nullTimePtr(p.PublishedAt),
+				CreatedAt:   p.CreatedAt,
+				ReadAt:      nullTimePtr(p.ReadAt),
+				Description: nullStringPtr(p.Description),
+			}
+		}
+		return writeRecords(os.Stdout, s.Output, records)
+	}
+	if len(posts) == 0 {
+		fmt.Println("No posts found.")
+		return nil
+	}
+	for _, post := range posts {
+		desc := ""
+		if post.Description.Valid {
+			desc = post.Description.String
+		}
+		published := "unknown"
+		if post.PublishedAt.Valid {
+			published = post.PublishedAt.Time.Format(time.RFC3339)
+		}
+		title := post.Title
+		if post.ReadAt.Valid {
+			title += " (read)"
+		}
+		fmt.Printf("ID: %s\nTitle: %s\nFeed: %s\nURL: %s\nPublished: %s\nDescription: %s\n---\n", post.ID, title, post.FeedName, post.Url, published, desc)
+	}
+	return nil
+}
+
+// resolveFollowedFeed finds a feed the user follows by url or, failing that,
+// by name
+func resolveFollowedFeed(s *State, user database.User, urlOrName string) (uuid.UUID, error) {
+	follows, err := s.Db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get feed follows: %v", err)
+	}
+	feed, err := s.Db.GetFeedByUrl(context.Background(), urlOrName)
+	if err == nil {
+		for _, f := range follows {
+			if f.FeedID == feed.ID {
+				return feed.ID, nil
+			}
+		}
+		return uuid.Nil, fmt.Errorf("you are not following the feed with url %s", urlOrName)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("could not look up feed %s: %v", urlOrName, err)
+	}
+	var matches []uuid.UUID
+	for _, f := range follows {
+		if strings.EqualFold(f.FeedName, urlOrName) {
+			matches = append(matches, f.FeedID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("you are not following a feed with url or name %q", urlOrName)
+	case 1:
+		return matches[0], nil
+	default:
+		return uuid.Nil, fmt.Errorf("%d followed feeds are named %q; use the feed url instead", len(matches), urlOrName)
+	}
+}
+
+// parseTimeBound parses a --since/--until value: either a duration before
+// now such as "36h" or "7d", or a date in any format feeds use
+func parseTimeBound(value string, loc *time.Location) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n).UTC(), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d).UTC(), nil
+	}
+	t, err := rssfeed.ParseDate(value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a duration nor a date", value)
+	}
+	return t.UTC(), nil
+}
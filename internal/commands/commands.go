@@ -3,27 +3,40 @@ package commands
 import (
 	"aggreGATOR/internal/config"
 	"aggreGATOR/internal/database"
-	"aggreGATOR/internal/rssfeed"
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type State struct {
-	Db  *database.Queries
-	Cfg *config.Config
+	// Conn is the connection pool Db runs on, used for schema migrations
+	Conn   *sql.DB
+	Db     *database.Queries
+	Cfg    *config.Config
+	Output OutputFormat
+	// Migrate, when set, brings the schema up to date. Commands call it
+	// after their arguments are validated and just before their handler
+	// runs, so help and usage errors never touch the database.
+	Migrate func() error
 }
 
 type Command struct {
 	Name string
 	Args []string
+	// flags holds the parsed flags of commands registered with Add
+	flags *flag.FlagSet
 }
 
 type Commands struct {
 	Handlers map[string]func(*State, Command) error
+	Specs    map[string]Spec
 }
 
 func (c *Commands) Run(s *State, cmd Command) error {
@@ -40,24 +53,289 @@ func (c *Commands) Register(name string, f func(*State, Command) error) {
 
 func DefaultCommands() *Commands {
 	cmds := &Commands{Handlers: make(map[string]func(*State, Command) error)}
-	cmds.Register("agg", handlerAgg)
-	cmds.Register("addfeed", middlewareLoggedIn(handlerAddFeed))
-	cmds.Register("feeds", handlerFeeds)
-	cmds.Register("follow", middlewareLoggedIn(handlerFollow))
-	cmds.Register("following", middlewareLoggedIn(handlerFollowing))
-	cmds.Register("login", handlerLogin)
-	cmds.Register("reset", handlerReset)
-	cmds.Register("users", handlerUsers)
-	cmds.Register("register", handlerRegister)
-	cmds.Register("unfollow", middlewareLoggedIn(handlerUnfollow))
-	cmds.Register("browse", middlewareLoggedIn(handlerBrowse))
+	cmds.Add(Spec{
+		Name:        "help",
+		Description: "List commands, or show the usage of one command.",
+		Args: []Arg{
+			{Name: "command", Description: "command to describe", Optional: true, Complete: CompleteCommands},
+			{Name: "subcommand", Description: "subcommand to describe, for commands that have them", Optional: true},
+		},
+		Handler:        cmds.handlerHelp,
+		SkipMigrations: true,
+	})
+	cmds.Add(Spec{
+		Name:        "agg",
+		Description: "Fetch feeds continuously, or once with --once, --all or --feed.",
+		Args:        []Arg{{Name: "time_between_reqs", Description: "interval between fetch rounds, e.g. 1m or 10s", Optional: true, Complete: CompleteDurations}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.Int("workers", 1, "number of feeds to fetch in parallel")
+			fs.Int("max-failures", 10, "disable a feed after this many consecutive failures (0 never disables)")
+			fs.Bool("once", false, "fetch every due feed once and exit")
+			fs.Bool("all", false, "fetch every feed once, including ones backing off or disabled, and exit")
+			fs.String("feed", "", "fetch only the feed with this `url` and exit")
+		},
+		Handler: handlerAgg,
+	})
+	cmds.Add(Spec{
+		Name:        "addfeed",
+		Description: "Add a feed and follow it.",
+		Args: []Arg{
+			{Name: "name", Description: "display name of the feed"},
+			{Name: "url", Description: "url of the RSS, Atom or JSON feed"},
+		},
+		Handler: middlewareLoggedIn(handlerAddFeed),
+	})
+	cmds.Add(Spec{
+		Name:        "feeds",
+		Description: "List every feed.",
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("status", false, "show fetch health of each feed")
+		},
+		Handler: handlerFeeds,
+	})
+	cmds.Add(Spec{
+		Name:        "feed",
+		Description: "Show, rename, move or delete a feed.",
+		Subcommands: []Spec{
+			{
+				Name:        "show",
+				Description: "Show a feed's details, follower and post counts and fetch health.",
+				Args:        []Arg{{Name: "url", Description: "url of the feed", Complete: CompleteFeeds}},
+				Handler:     handlerFeedShow,
+			},
+			{
+				Name:        "rename",
+				Description: "Rename a feed you added.",
+				Args: []Arg{
+					{Name: "url", Description: "url of the feed", Complete: CompleteFeeds},
+					{Name: "name", Description: "new name of the feed"},
+				},
+				Handler: middlewareLoggedIn(handlerFeedRename),
+			},
+			{
+				Name:        "set-url",
+				Description: "Change the url of a feed you added, keeping its posts and followers.",
+				Args: []Arg{
+					{Name: "url", Description: "current url of the feed", Complete: CompleteFeeds},
+					{Name: "new_url", Description: "new url of the feed"},
+				},
+				Handler: middlewareLoggedIn(handlerFeedSetURL),
+			},
+			{
+				Name:        "delete",
+				Description: "Delete a feed you added, with its posts and everyone's follows of it.",
+				Args:        []Arg{{Name: "url", Description: "url of the feed", Complete: CompleteFeeds}},
+				Flags: func(fs *flag.FlagSet) {
+					fs.Bool("yes", false, "don't ask for confirmation")
+				},
+				Handler: middlewareLoggedIn(handlerFeedDelete),
+			},
+		},
+	})
+	cmds.Add(Spec{
+		Name:        "follow",
+		Description: "Follow an existing feed.",
+		Args:        []Arg{{Name: "url", Description: "url of the feed", Complete: CompleteFeeds}},
+		Handler:     middlewareLoggedIn(handlerFollow),
+	})
+	cmds.Add(Spec{
+		Name:        "following",
+		Description: "List the feeds you follow.",
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("tag", "", "only list feeds with this tag")
+		},
+		Handler: middlewareLoggedIn(handlerFollowing),
+	})
+	cmds.Add(Spec{
+		Name:        "rename-follow",
+		Description: "Set your own title for a feed you follow, or clear it with an empty title.",
+		Args: []Arg{
+			{Name: "feed", Description: "url or current name of the followed feed", Complete: CompleteFeeds},
+			{Name: "title", Description: "title shown to you in following, browse and exports"},
+		},
+		Handler: middlewareLoggedIn(handlerRenameFollow),
+	})
+	cmds.Add(Spec{
+		Name:        "tag",
+		Description: "Add a tag to a feed you follow, to group feeds by topic.",
+		Args: []Arg{
+			{Name: "feed", Description: "url or name of the followed feed", Complete: CompleteFeeds},
+			{Name: "tag", Description: "tag to add, e.g. security"},
+		},
+		Handler: middlewareLoggedIn(handlerTag),
+	})
+	cmds.Add(Spec{
+		Name:        "untag",
+		Description: "Remove a tag from a feed you follow.",
+		Args: []Arg{
+			{Name: "feed", Description: "url or name of the followed feed", Complete: CompleteFeeds},
+			{Name: "tag", Description: "tag to remove"},
+		},
+		Handler: middlewareLoggedIn(handlerUntag),
+	})
+	cmds.Add(Spec{
+		Name:        "login",
+		Description: "Switch to an existing user.",
+		Args:        []Arg{{Name: "username", Description: "name of the user", Complete: CompleteUsers}},
+		Handler:     handlerLogin,
+	})
+	cmds.Add(Spec{
+		Name:        "reset",
+		Description: "Delete every user, feed and post, or only every feed or post.",
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("yes", false, "don't ask for confirmation")
+			fs.Bool("feeds-only", false, "delete every feed and post but keep users")
+			fs.Bool("posts-only", false, "delete every post except starred ones but keep users, feeds and follows")
+		},
+		Handler: handlerReset,
+	})
+	cmds.Add(Spec{
+		Name:        "user",
+		Description: "Manage a single user.",
+		Subcommands: []Spec{
+			{
+				Name:        "delete",
+				Description: "Delete a user, transferring or deleting the feeds they added.",
+				Args:        []Arg{{Name: "name", Description: "user to delete", Complete: CompleteUsers}},
+				Flags: func(fs *flag.FlagSet) {
+					fs.String("transfer-to", "", "give the user's feeds to this `user`")
+					fs.Bool("delete-feeds", false, "delete the user's feeds with their posts")
+					fs.Bool("yes", false, "don't ask for confirmation")
+				},
+				Handler: handlerUserDelete,
+			},
+		},
+	})
+	cmds.Add(Spec{
+		Name:        "users",
+		Description: "List every user.",
+		Handler:     handlerUsers,
+	})
+	cmds.Add(Spec{
+		Name:        "register",
+		Description: "Create a user and log in as them.",
+		Args:        []Arg{{Name: "username", Description: "name of the new user"}},
+		Handler:     handlerRegister,
+	})
+	cmds.Add(Spec{
+		Name:        "unfollow",
+		Description: "Stop following a feed.",
+		Args:        []Arg{{Name: "url", Description: "url of the feed", Complete: CompleteFeeds}},
+		Handler:     middlewareLoggedIn(handlerUnfollow),
+	})
+	cmds.Add(Spec{
+		Name:        "browse",
+		Description: "Show recent unread posts from the feeds you follow.",
+		Args:        []Arg{{Name: "limit", Description: "maximum number of posts to show (default 2)", Optional: true}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("feed", "", "only show posts from the followed feed with this `url or name`")
+			fs.String("since", "", "only show posts published after this `duration` ago (e.g. 24h, 7d) or date")
+			fs.String("until", "", "only show posts published before this `duration` ago or date")
+			fs.Int("offset", 0, "number of posts to skip, for paging")
+			fs.Bool("all", false, "include posts that have already been read")
+			fs.String("tag", "", "only show posts from followed feeds with this tag")
+			fs.Bool("mark-read", false, "mark the posts shown as read")
+		},
+		Handler: middlewareLoggedIn(handlerBrowse),
+	})
+	cmds.Add(Spec{
+		Name:        "read",
+		Description: "Mark a post as read.",
+		Args:        []Arg{{Name: "post", Description: "id or url of the post"}},
+		Handler:     middlewareLoggedIn(handlerRead),
+	})
+	cmds.Add(Spec{
+		Name:        "unread",
+		Description: "Mark a post as unread.",
+		Args:        []Arg{{Name: "post", Description: "id or url of the post"}},
+		Handler:     middlewareLoggedIn(handlerUnread),
+	})
+	cmds.Add(Spec{
+		Name:        "mark-all-read",
+		Description: "Mark every post from the feeds you follow as read.",
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("feed", "", "only mark posts from the followed feed with this `url or name`")
+			fs.String("before", "", "only mark posts published before this `duration` ago (e.g. 7d) or date")
+		},
+		Handler: middlewareLoggedIn(handlerMarkAllRead),
+	})
+	cmds.Add(Spec{
+		Name:        "star",
+		Description: "Add a post to your reading list.",
+		Args:        []Arg{{Name: "post", Description: "id or url of the post"}},
+		Handler:     middlewareLoggedIn(handlerStar),
+	})
+	cmds.Add(Spec{
+		Name:        "unstar",
+		Description: "Remove a post from your reading list.",
+		Args:        []Arg{{Name: "post", Description: "id or url of the post"}},
+		Handler:     middlewareLoggedIn(handlerUnstar),
+	})
+	cmds.Add(Spec{
+		Name:        "starred",
+		Description: "Show your reading list, most recently starred first.",
+		Args:        []Arg{{Name: "limit", Description: "maximum number of posts to show (default 20)", Optional: true}},
+		Handler:     middlewareLoggedIn(handlerStarred),
+	})
+	cmds.Add(Spec{
+		Name:        "search",
+		Description: "Search post titles and descriptions.",
+		Args:        []Arg{{Name: "query", Description: "words to search for; put them after -- to exclude words with -, e.g. search -- go -generics", Variadic: true}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("all", false, "search posts from every feed, not just followed ones")
+			fs.String("feed", "", "only search posts from the feed with this `url or name`")
+			fs.String("since", "", "only search posts published after this `duration` ago (e.g. 7d) or date")
+			fs.Int("limit", 10, "maximum number of results")
+		},
+		Handler: middlewareLoggedIn(handlerSearch),
+	})
+	cmds.Add(Spec{
+		Name:        "import-opml",
+		Description: "Create and follow every feed listed in an OPML file.",
+		Args:        []Arg{{Name: "file", Description: "path of the OPML file", Complete: CompleteFiles}},
+		Handler:     middlewareLoggedIn(handlerImportOPML),
+	})
+	cmds.Add(Spec{
+		Name:        "export-opml",
+		Description: "Write the feeds you follow as an OPML document.",
+		Args:        []Arg{{Name: "file", Description: "path to write to (default stdout)", Optional: true, Complete: CompleteFiles}},
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("all", false, "export every feed, not just the ones you follow")
+		},
+		Handler: middlewareLoggedIn(handlerExportOPML),
+	})
+	cmds.Add(Spec{
+		Name:        "tui",
+		Description: "Open the full-screen reader for the feeds you follow.",
+		Handler:     middlewareLoggedIn(handlerTUI),
+	})
+	cmds.Add(Spec{
+		Name:           "completion",
+		Description:    "Print a shell completion script.",
+		Args:           []Arg{{Name: "shell", Description: "bash, zsh or fish", Choices: completionShells}},
+		Handler:        cmds.handlerCompletion,
+		SkipMigrations: true,
+	})
+	cmds.Add(Spec{
+		Name:           "migrate",
+		Description:    "Apply or roll back database migrations, or show which are applied.",
+		Args:           []Arg{{Name: "action", Description: "up, down (rolls back the latest migration) or status", Choices: []string{"up", "down", "status"}}},
+		Handler:        handlerMigrate,
+		SkipMigrations: true,
+	})
+	cmds.Add(Spec{
+		Name:    "__complete",
+		Args:    []Arg{{Name: "kind"}},
+		Handler: handlerComplete,
+		Hidden:  true,
+		// Runs on every Tab press, so it must never take the migration lock
+		// or change the schema
+		SkipMigrations: true,
+	})
 	return cmds
 }
 
 func handlerLogin(s *State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("login requires a username argument")
-	}
 	username := cmd.Args[0]
 	_, err := s.Db.GetUser(context.Background(), username)
 	if err != nil {
@@ -72,9 +350,6 @@ func handlerLogin(s *State, cmd Command) error {
 }
 
 func handlerRegister(s *State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("register requires a username argument")
-	}
 	name := cmd.Args[0]
 	// Check if user exists
 	_, err := s.Db.GetUser(context.Background(), name)
@@ -82,7 +357,7 @@ func handlerRegister(s *State, cmd Command) error {
 		return fmt.Errorf("user '%s' already exists", name)
 	}
 	id := uuid.New()
-	now := time.Now()
+	now := time.Now().UTC()
 	params := database.CreateUserParams{
 		ID:        id,
 		CreatedAt: now,
@@ -102,13 +377,12 @@ func handlerRegister(s *State, cmd Command) error {
 	return nil
 }
 
-func handlerReset(s *State, cmd Command) error {
-	err := s.Db.DeleteUsers(context.Background())
-	if err != nil {
-		return fmt.Errorf("reset failed: %v", err)
-	}
-	fmt.Println("All users deleted successfully.")
-	return nil
+type userRecord struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func handlerUsers(s *State, cmd Command) error {
@@ -117,6 +391,13 @@ func handlerUsers(s *State, cmd Command) error {
 		return fmt.Errorf("failed to get users: %v", err)
 	}
 	current := s.Cfg.CurrentUserName
+	if s.Output.structured() {
+		records := make([]userRecord, len(users))
+		for i, u := range users {
+			records[i] = userRecord{ID: u.ID, Name: u.Name, Current: u.Name == current, CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt}
+		}
+		return writeRecords(os.Stdout, s.Output, records)
+	}
 	for _, u := range users {
 		if u.Name == current {
 			fmt.Printf("* %s (current)\n", u.Name)
@@ -127,68 +408,7 @@ func handlerUsers(s *State, cmd Command) error {
 	return nil
 }
 
-func handlerAgg(s *State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("agg requires a time_between_reqs argument (e.g. 1m, 10s)")
-	}
-	timeBetweenRequests, err := time.ParseDuration(cmd.Args[0])
-	if err != nil {
-		return fmt.Errorf("invalid duration: %v", err)
-	}
-	fmt.Printf("Collecting feeds every %s\n", timeBetweenRequests)
-	ticker := time.NewTicker(timeBetweenRequests)
-	defer ticker.Stop()
-	for {
-		scrapeFeeds(s)
-		<-ticker.C
-	}
-}
-
-func scrapeFeeds(s *State) {
-	feed, err := s.Db.GetNextFeedToFetch(context.Background())
-	if err != nil {
-		fmt.Println("No feeds to fetch or error:", err)
-		return
-	}
-	_ = s.Db.MarkFeedFetched(context.Background(), feed.ID)
-	rss, err := rssfeed.FetchFeed(context.Background(), feed.Url)
-	if err != nil {
-		fmt.Printf("Error fetching feed %s: %v\n", feed.Name, err)
-		return
-	}
-	fmt.Printf("Feed: %s\n", feed.Name)
-	for _, item := range rss.Channel.Items {
-		id := uuid.New()
-		now := time.Now()
-		url := item.Link
-		if url == "" {
-			url = item.Guid
-		}
-		publishedAt := now // TODO: parse from item.PublishedAt or item.PubDate
-		params := database.CreatePostParams{
-			ID:          id,
-			CreatedAt:   now,
-			UpdatedAt:   now,
-			Title:       item.Title,
-			Url:         url,
-			Description: sql.NullString{String: item.Description, Valid: item.Description != ""},
-			PublishedAt: publishedAt,
-			FeedID:      feed.ID,
-		}
-		_, err := s.Db.CreatePost(context.Background(), params)
-		if err != nil {
-			if err.Error() == "ERROR: duplicate key value violates unique constraint \"posts_url_key\" (SQLSTATE 23505)" {
-				continue
-			}
-			fmt.Printf("Error saving post '%s': %v\n", item.Title, err)
-		}
-	}
-}
-
 func handlerAddFeed(s *State, cmd Command, user database.User) error {
-	if len(cmd.Args) < 2 {
-		return fmt.Errorf("addfeed requires name and url arguments")
-	}
 	name := cmd.Args[0]
 	url := cmd.Args[1]
 	params := database.CreateFeedParams{
@@ -203,7 +423,7 @@ func handlerAddFeed(s *State, cmd Command, user database.User) error {
 	fmt.Printf("Feed created: ID=%v Name=%s Url=%s UserID=%v\n", feed.ID, feed.Name, feed.Url, feed.UserID)
 
 	id := uuid.New()
-	now := time.Now()
+	now := time.Now().UTC()
 	ff, err := s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
 		ID:        id,
 		CreatedAt: now,
@@ -218,30 +438,148 @@ func handlerAddFeed(s *State, cmd Command, user database.User) error {
 	return nil
 }
 
+type feedRecord struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	CreatedBy     string     `json:"created_by"`
+	UserID        uuid.UUID  `json:"user_id" table:"-"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" table:"-"`
+	Followers     int64      `json:"followers"`
+	Posts         int64      `json:"posts"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+}
+
+type feedStatusRecord struct {
+	ID            uuid.UUID  `json:"id" table:"-"`
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	Status        string     `json:"status"`
+	FailureCount  int32      `json:"failure_count"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	LastSuccessAt *time.Time `json:"last_success_at"`
+	NextFetchAt   *time.Time `json:"next_fetch_at"`
+	DisabledAt    *time.Time `json:"disabled_at" table:"-"`
+	LastError     *string    `json:"last_error"`
+}
+
 func handlerFeeds(s *State, cmd Command) error {
+	if cmd.Bool("status") {
+		return printFeedStatus(s)
+	}
 	feeds, err := s.Db.GetFeedsWithUser(context.Background())
 	if err != nil {
 		return err
 	}
+	if s.Output.structured() {
+		records := make([]feedRecord, len(feeds))
+		for i, f := range feeds {
+			records[i] = feedRecord{
+				ID:            f.ID,
+				Name:          f.Name,
+				URL:           f.Url,
+				CreatedBy:     f.UserName,
+				UserID:        f.UserID,
+				CreatedAt:     f.CreatedAt,
+				UpdatedAt:     f.UpdatedAt,
+				Followers:     f.FollowerCount,
+				Posts:         f.PostCount,
+				LastFetchedAt: nullTimePtr(f.LastFetchedAt),
+			}
+		}
+		return writeRecords(os.Stdout, s.Output, records)
+	}
 
 	for _, feed := range feeds {
 		fmt.Printf("- %s\n  url: %s\n  created by: %s\n", feed.Name, feed.Url, feed.UserName)
+		fmt.Printf("  followers: %d, posts: %d, last fetched: %s\n", feed.FollowerCount, feed.PostCount, formatNullTime(feed.LastFetchedAt))
 	}
 
 	return nil
 }
 
-func handlerFollow(s *State, cmd Command, user database.User) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("follow requires a feed url argument")
+// printFeedStatus lists every feed with its failure state, disabled and
+// failing feeds first
+func printFeedStatus(s *State) error {
+	feeds, err := s.Db.GetFeedsByStatus(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get feed status: %v", err)
+	}
+	if s.Output.structured() {
+		records := make([]feedStatusRecord, len(feeds))
+		for i, f := range feeds {
+			status := "ok"
+			switch {
+			case f.DisabledAt.Valid:
+				status = "disabled"
+			case f.FailureCount > 0:
+				status = "failing"
+			}
+			records[i] = feedStatusRecord{
+				ID:            f.ID,
+				Name:          f.Name,
+				URL:           f.Url,
+				Status:        status,
+				FailureCount:  f.FailureCount,
+				LastFetchedAt: nullTimePtr(f.LastFetchedAt),
+				LastSuccessAt: nullTimePtr(f.LastSuccessAt),
+				NextFetchAt:   nullTimePtr(f.NextFetchAt),
+				DisabledAt:    nullTimePtr(f.DisabledAt),
+				LastError:     nullStringPtr(f.LastError),
+			}
+		}
+		return writeRecords(os.Stdout, s.Output, records)
+	}
+	for _, feed := range feeds {
+		state := "ok"
+		switch {
+		case feed.DisabledAt.Valid:
+			state = fmt.Sprintf("disabled since %s", feed.DisabledAt.Time.Format(time.RFC3339))
+		case feed.FailureCount > 0:
+			state = fmt.Sprintf("failing (%d consecutive failures)", feed.FailureCount)
+		}
+		fmt.Printf("- %s [%s]\n  url: %s\n", feed.Name, state, feed.Url)
+		fmt.Printf("  last fetched: %s\n  last success: %s\n", formatNullTime(feed.LastFetchedAt), formatNullTime(feed.LastSuccessAt))
+		if feed.NextFetchAt.Valid && !feed.DisabledAt.Valid {
+			fmt.Printf("  next attempt: %s\n", feed.NextFetchAt.Time.Format(time.RFC3339))
+		}
+		if feed.LastError.Valid {
+			fmt.Printf("  last error: %s\n", feed.LastError.String)
+		}
+	}
+	return nil
+}
+
+func formatNullTime(t sql.NullTime) string {
+	if !t.Valid {
+		return "never"
+	}
+	return t.Time.Format(time.RFC3339)
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
 	}
+	return &t.Time
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func handlerFollow(s *State, cmd Command, user database.User) error {
 	url := cmd.Args[0]
 	feed, err := s.Db.GetFeedByUrl(context.Background(), url)
 	if err != nil {
 		return fmt.Errorf("could not find feed with url %s: %v", url, err)
 	}
 	id := uuid.New()
-	now := time.Now()
+	now := time.Now().UTC()
 	ff, err := s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
 		ID:        id,
 		CreatedAt: now,
@@ -256,26 +594,70 @@ func handlerFollow(s *State, cmd Command, user database.User) error {
 	return nil
 }
 
+type followRecord struct {
+	ID         uuid.UUID `json:"id" table:"-"`
+	FeedID     uuid.UUID `json:"feed_id"`
+	FeedName   string    `json:"feed_name"`
+	Title      *string   `json:"title" table:"-"`
+	FeedURL    string    `json:"feed_url"`
+	Tags       []string  `json:"tags"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
 func handlerFollowing(s *State, cmd Command, user database.User) error {
 	follows, err := s.Db.GetFeedFollowsForUser(context.Background(), user.ID)
 	if err != nil {
 		return fmt.Errorf("failed to get feed follows: %v", err)
 	}
+	tags, err := followTags(s, user)
+	if err != nil {
+		return err
+	}
+	var tag string
+	if filter := cmd.String("tag"); filter != "" {
+		tag, err = normalizeTag(filter)
+		if err != nil {
+			return err
+		}
+		var tagged []database.GetFeedFollowsForUserRow
+		for _, f := range follows {
+			if slices.Contains(tags[f.FeedID], tag) {
+				tagged = append(tagged, f)
+			}
+		}
+		follows = tagged
+	}
+	if s.Output.structured() {
+		records := make([]followRecord, len(follows))
+		for i, f := range follows {
+			feedTags := tags[f.FeedID]
+			if feedTags == nil {
+				feedTags = []string{}
+			}
+			records[i] = followRecord{ID: f.ID, FeedID: f.FeedID, FeedName: f.FeedName, Title: nullStringPtr(f.Title), FeedURL: f.FeedUrl, Tags: feedTags, FollowedAt: f.CreatedAt}
+		}
+		return writeRecords(os.Stdout, s.Output, records)
+	}
+	if len(follows) == 0 && tag != "" {
+		fmt.Printf("None of the feeds you follow are tagged '%s'.\n", tag)
+		return nil
+	}
 	if len(follows) == 0 {
 		fmt.Println("You are not following any feeds.")
 		return nil
 	}
 	fmt.Println("Feeds you are following:")
 	for _, f := range follows {
-		fmt.Printf("* %s\n", f.FeedName)
+		if feedTags := tags[f.FeedID]; len(feedTags) > 0 {
+			fmt.Printf("* %s [%s]\n", f.FeedName, strings.Join(feedTags, ", "))
+		} else {
+			fmt.Printf("* %s\n", f.FeedName)
+		}
 	}
 	return nil
 }
 
 func handlerUnfollow(s *State, cmd Command, user database.User) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("unfollow requires a feed url argument")
-	}
 	url := cmd.Args[0]
 	feed, err := s.Db.GetFeedByUrl(context.Background(), url)
 	if err != nil {
@@ -293,6 +675,30 @@ func handlerUnfollow(s *State, cmd Command, user database.User) error {
 	return nil
 }
 
+// rename-follow command: sets the title the current user sees for a followed
+// feed; an empty title goes back to the feed's own name
+func handlerRenameFollow(s *State, cmd Command, user database.User) error {
+	feedID, err := resolveFollowedFeed(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSpace(cmd.Args[1])
+	_, err = s.Db.UpdateFeedFollowTitle(context.Background(), database.UpdateFeedFollowTitleParams{
+		UserID: user.ID,
+		FeedID: feedID,
+		Title:  sql.NullString{String: title, Valid: title != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename feed: %v", err)
+	}
+	if title == "" {
+		fmt.Printf("Cleared your title for %s\n", cmd.Args[0])
+	} else {
+		fmt.Printf("Renamed %s to '%s'\n", cmd.Args[0], title)
+	}
+	return nil
+}
+
 func middlewareLoggedIn(handler func(s *State, cmd Command, user database.User) error) func(*State, Command) error {
 	return func(s *State, cmd Command) error {
 		userName := s.Cfg.CurrentUserName
@@ -303,35 +709,3 @@ func middlewareLoggedIn(handler func(s *State, cmd Command, user database.User)
 		return handler(s, cmd, user)
 	}
 }
-
-// browse command: prints recent posts for the current user, with optional limit
-func handlerBrowse(s *State, cmd Command, user database.User) error {
-	limit := 2
-	if len(cmd.Args) > 0 {
-		var l int
-		_, err := fmt.Sscanf(cmd.Args[0], "%d", &l)
-		if err == nil && l > 0 {
-			limit = l
-		}
-	}
-	params := database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  int32(limit),
-	}
-	posts, err := s.Db.GetPostsForUser(context.Background(), params)
-	if err != nil {
-		return fmt.Errorf("failed to get posts: %v", err)
-	}
-	if len(posts) == 0 {
-		fmt.Println("No posts found.")
-		return nil
-	}
-	for _, post := range posts {
-		desc := ""
-		if post.Description.Valid {
-			desc = post.Description.String
-		}
-		fmt.Printf("Title: %s\nURL: %s\nPublished: %s\nDescription: %s\n---\n", post.Title, post.Url, post.PublishedAt.Format(time.RFC3339), desc)
-	}
-	return nil
-}
@@ -1,8 +1,14 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"flag"
+	"io"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRegisterAndRunCommand(t *testing.T) {
@@ -62,3 +68,429 @@ func TestMultipleCommandRegistration(t *testing.T) {
 		t.Error("not all handlers were called")
 	}
 }
+
+func TestParseFlagsInterleaved(t *testing.T) {
+	fs := newFlagSet("agg")
+	workers := fs.Int("workers", 1, "")
+	args, err := parseFlags(fs, []string{"1m", "--workers", "8", "extra"})
+	if err != nil {
+		t.Fatalf("parseFlags() error: %v", err)
+	}
+	if *workers != 8 {
+		t.Errorf("workers: got %d, want 8", *workers)
+	}
+	if len(args) != 2 || args[0] != "1m" || args[1] != "extra" {
+		t.Errorf("positional args: got %v, want [1m extra]", args)
+	}
+}
+
+func TestParseFlagsUnknownFlag(t *testing.T) {
+	fs := newFlagSet("agg")
+	if _, err := parseFlags(fs, []string{"1m", "--bogus"}); err == nil {
+		t.Error("expected error for unknown flag, got nil")
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{20, 24 * time.Hour},
+		{1000, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(tt.failures); got != tt.want {
+			t.Errorf("backoffDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Now()
+	got, err := parseTimeBound("7d", time.UTC)
+	if err != nil {
+		t.Fatalf("parseTimeBound(7d) error: %v", err)
+	}
+	if diff := now.AddDate(0, 0, -7).Sub(got); diff < -time.Minute || diff > time.Minute {
+		t.Errorf("parseTimeBound(7d) = %v, want about a week ago", got)
+	}
+	got, err = parseTimeBound("36h", time.UTC)
+	if err != nil {
+		t.Fatalf("parseTimeBound(36h) error: %v", err)
+	}
+	if diff := now.Add(-36 * time.Hour).Sub(got); diff < -time.Minute || diff > time.Minute {
+		t.Errorf("parseTimeBound(36h) = %v, want 36 hours ago", got)
+	}
+	got, err = parseTimeBound("2024-05-01", time.FixedZone("CEST", 2*3600))
+	if err != nil {
+		t.Fatalf("parseTimeBound(2024-05-01) error: %v", err)
+	}
+	if want := time.Date(2024, 4, 30, 22, 0, 0, 0, time.UTC); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("parseTimeBound(2024-05-01) = %v, want %v", got, want)
+	}
+	if _, err := parseTimeBound("soon", time.UTC); err == nil {
+		t.Error("expected error for invalid bound, got nil")
+	}
+}
+
+type testRecord struct {
+	ID      int        `json:"id"`
+	Name    string     `json:"name"`
+	Seen    *time.Time `json:"seen"`
+	Details string     `json:"details" table:"-"`
+}
+
+func TestWriteRecords(t *testing.T) {
+	seen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []testRecord{
+		{ID: 1, Name: "alpha", Seen: &seen, Details: "long"},
+		{ID: 2, Name: "b, \"quoted\""},
+	}
+	tests := []struct {
+		format OutputFormat
+		want   string
+	}{
+		{OutputCSV, "id,name,seen,details\n1,alpha,2024-01-02T03:04:05Z,long\n2,\"b, \"\"quoted\"\"\",,\n"},
+		{OutputTable, "ID  NAME         SEEN\n1   alpha        2024-01-02T03:04:05Z\n2   b, \"quoted\"  \n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeRecords(&buf, tt.format, records); err != nil {
+			t.Fatalf("writeRecords(%s) error: %v", tt.format, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("writeRecords(%s) =\n%q\nwant\n%q", tt.format, buf.String(), tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeRecords(&buf, OutputJSON, records); err != nil {
+		t.Fatalf("writeRecords(json) error: %v", err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0]["seen"] != "2024-01-02T03:04:05Z" || decoded[1]["seen"] != nil {
+		t.Errorf("unexpected JSON output: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeRecords(&buf, OutputJSON, []testRecord(nil)); err != nil {
+		t.Fatalf("writeRecords(json, nil) error: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty JSON output: got %q, want []", buf.String())
+	}
+}
+
+func TestExtractOutputFlag(t *testing.T) {
+	format, rest, err := ExtractOutputFlag([]string{"--output", "json", "-o=csv", "search", "-o", "--feed", "x"})
+	if err != nil {
+		t.Fatalf("ExtractOutputFlag() error: %v", err)
+	}
+	if format != OutputCSV {
+		t.Errorf("format: got %q, want %q", format, OutputCSV)
+	}
+	// Options after the command name are left to the command
+	if strings.Join(rest, " ") != "search -o --feed x" {
+		t.Errorf("rest: got %v", rest)
+	}
+	if _, _, err := ExtractOutputFlag([]string{"--output", "xml", "users"}); err == nil {
+		t.Error("expected error for unknown format, got nil")
+	}
+	if _, _, err := ExtractOutputFlag([]string{"-o"}); err == nil {
+		t.Error("expected error for missing value, got nil")
+	}
+}
+
+func TestOutputFlagAfterCommand(t *testing.T) {
+	cmds := &Commands{Handlers: make(map[string]func(*State, Command) error)}
+	var got []string
+	spec := Spec{Name: "search", Args: []Arg{{Name: "query", Variadic: true}}, Handler: func(s *State, c Command) error {
+		got = c.Args
+		return nil
+	}}
+	cmds.Add(spec)
+	s := &State{Output: OutputText}
+	if err := cmds.Run(s, Command{Name: "search", Args: []string{"go", "-o", "json"}}); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if s.Output != OutputJSON || strings.Join(got, " ") != "go" {
+		t.Errorf("output = %q, args = %v", s.Output, got)
+	}
+	s = &State{Output: OutputTable}
+	if err := cmds.Run(s, Command{Name: "search", Args: []string{"--", "-o"}}); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if s.Output != OutputTable || strings.Join(got, " ") != "-o" {
+		t.Errorf("after --: output = %q, args = %v", s.Output, got)
+	}
+	if err := cmds.Run(&State{}, Command{Name: "search", Args: []string{"go", "--output", "xml"}}); err == nil {
+		t.Error("expected error for unknown format, got nil")
+	}
+}
+
+func testSpec() Spec {
+	return Spec{
+		Name:        "test",
+		Description: "A test command.",
+		Args: []Arg{
+			{Name: "url", Description: "feed url"},
+			{Name: "limit", Description: "maximum results", Optional: true},
+		},
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("all", false, "include everything")
+			fs.Int("workers", 1, "number of workers")
+		},
+	}
+}
+
+func TestSpecUsage(t *testing.T) {
+	if got, want := testSpec().Usage(), "test <url> [limit] [flags]"; got != want {
+		t.Errorf("Usage() = %q, want %q", got, want)
+	}
+	variadic := Spec{Name: "search", Args: []Arg{{Name: "query", Variadic: true}}}
+	if got, want := variadic.Usage(), "search <query...>"; got != want {
+		t.Errorf("Usage() = %q, want %q", got, want)
+	}
+}
+
+func TestSpecParse(t *testing.T) {
+	spec := testSpec()
+	cmd, err := spec.parse([]string{"http://x", "--workers", "4", "5", "--all"})
+	if err != nil {
+		t.Fatalf("parse() error: %v", err)
+	}
+	if len(cmd.Args) != 2 || cmd.Args[0] != "http://x" || cmd.Args[1] != "5" {
+		t.Errorf("args: got %v", cmd.Args)
+	}
+	if !cmd.Bool("all") || cmd.Int("workers") != 4 {
+		t.Errorf("flags: all=%v workers=%d", cmd.Bool("all"), cmd.Int("workers"))
+	}
+	if n, err := cmd.intArg(1, "limit", 2); err != nil || n != 5 {
+		t.Errorf("intArg() = %d, %v; want 5", n, err)
+	}
+
+	cmd, err = spec.parse([]string{"http://x"})
+	if err != nil {
+		t.Fatalf("parse() error: %v", err)
+	}
+	if cmd.Int("workers") != 1 {
+		t.Errorf("default workers: got %d, want 1", cmd.Int("workers"))
+	}
+	if n, err := cmd.intArg(1, "limit", 2); err != nil || n != 2 {
+		t.Errorf("intArg() default = %d, %v; want 2", n, err)
+	}
+
+	for _, args := range [][]string{{}, {"a", "b", "c"}, {"a", "--bogus"}} {
+		if _, err := spec.parse(args); err == nil {
+			t.Errorf("parse(%q): expected error", args)
+		}
+	}
+	cmd, err = spec.parse([]string{"--all", "--", "http://x", "-5"})
+	if err != nil {
+		t.Fatalf("parse() with -- error: %v", err)
+	}
+	if len(cmd.Args) != 2 || cmd.Args[1] != "-5" || !cmd.Bool("all") {
+		t.Errorf("args after --: got %v, all=%v", cmd.Args, cmd.Bool("all"))
+	}
+	search := Spec{Name: "search", Args: []Arg{{Name: "query", Variadic: true}}, Flags: func(fs *flag.FlagSet) { fs.Int("limit", 10, "") }}
+	cmd, err = search.parse([]string{"--limit", "5", "--", "go", "-generics", "--limit"})
+	if err != nil {
+		t.Fatalf("parse(search -- ...) error: %v", err)
+	}
+	if got := strings.Join(cmd.Args, " "); got != "go -generics --limit" || cmd.Int("limit") != 5 {
+		t.Errorf("search args = %q, limit = %d", got, cmd.Int("limit"))
+	}
+
+	if _, err := spec.parse([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("parse(-h): got %v, want flag.ErrHelp", err)
+	}
+	bad := Command{Args: []string{"abc"}}
+	if _, err := bad.intArg(0, "limit", 2); err == nil {
+		t.Error("intArg(abc): expected error")
+	}
+}
+
+func TestAddValidatesBeforeHandler(t *testing.T) {
+	cmds := &Commands{Handlers: make(map[string]func(*State, Command) error)}
+	called := false
+	spec := testSpec()
+	spec.Handler = func(s *State, c Command) error {
+		called = true
+		return nil
+	}
+	cmds.Add(spec)
+	if err := cmds.Run(&State{}, Command{Name: "test"}); err == nil {
+		t.Error("expected error for missing argument")
+	}
+	if called {
+		t.Error("handler ran despite invalid arguments")
+	}
+	if err := cmds.Run(&State{}, Command{Name: "test", Args: []string{"http://x"}}); err != nil || !called {
+		t.Errorf("Run() = %v, called = %v", err, called)
+	}
+}
+
+func TestSubcommands(t *testing.T) {
+	cmds := &Commands{Handlers: make(map[string]func(*State, Command) error)}
+	var got Command
+	cmds.Add(Spec{
+		Name: "feed",
+		Subcommands: []Spec{
+			{Name: "show", Args: []Arg{{Name: "url"}}, Handler: func(s *State, c Command) error {
+				got = c
+				return nil
+			}},
+			{Name: "delete", Args: []Arg{{Name: "url"}}, Handler: func(s *State, c Command) error {
+				t.Error("delete ran instead of show")
+				return nil
+			}},
+		},
+	})
+	if err := cmds.Run(&State{}, Command{Name: "feed", Args: []string{"show", "http://x"}}); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got.Name != "feed show" || len(got.Args) != 1 || got.Args[0] != "http://x" {
+		t.Errorf("subcommand got %+v", got)
+	}
+	for _, args := range [][]string{{}, {"bogus", "http://x"}, {"show"}, {"--"}, {"--", "show", "http://x"}, {"--bogus"}} {
+		if err := cmds.Run(&State{}, Command{Name: "feed", Args: args}); err == nil {
+			t.Errorf("Run(feed %q): expected error", args)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeCommandHelp(&buf, cmds.Specs["feed"])
+	if out := buf.String(); !strings.Contains(out, "Usage: gator feed <subcommand>") || !strings.Contains(out, "feed show <url>") {
+		t.Errorf("help output missing subcommands:\n%s", out)
+	}
+}
+
+func TestMigrateRunsOnlyBeforeHandler(t *testing.T) {
+	cmds := &Commands{Handlers: make(map[string]func(*State, Command) error)}
+	spec := testSpec()
+	spec.Handler = func(s *State, c Command) error { return nil }
+	cmds.Add(spec)
+	migrations := 0
+	s := &State{Migrate: func() error {
+		migrations++
+		return nil
+	}}
+	for _, args := range [][]string{{"-h"}, {}, {"a", "b", "c"}, {"http://x", "--bogus"}} {
+		cmds.Run(s, Command{Name: "test", Args: args})
+	}
+	if migrations != 0 {
+		t.Errorf("help and usage errors migrated the database %d time(s)", migrations)
+	}
+	if err := cmds.Run(s, Command{Name: "test", Args: []string{"http://x"}}); err != nil || migrations != 1 {
+		t.Errorf("Run() = %v, migrations = %d; want 1", err, migrations)
+	}
+	s.Migrate = func() error { return errors.New("connection refused") }
+	if err := cmds.Run(s, Command{Name: "test", Args: []string{"http://x"}}); err == nil {
+		t.Error("expected migration error")
+	}
+}
+
+func TestWriteCommandHelp(t *testing.T) {
+	var buf bytes.Buffer
+	writeCommandHelp(&buf, testSpec())
+	out := buf.String()
+	for _, want := range []string{"Usage: gator test <url> [limit] [flags]", "A test command.", "url    feed url", "--workers int  number of workers (default 1)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("help output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	cmds := DefaultCommands()
+	wants := map[string][]string{
+		"bash": {"\"login:0\") COMPREPLY=($(compgen -W \"$(gator __complete users", "\"follow:0\")", "\"agg:0\")", "\"agg:workers\") return 0", "\"feed:0\") COMPREPLY=($(compgen -W \"show rename set-url delete\"", "\"feed show:0\")", "cmd=\"$cmd $word\""},
+		"zsh":  {"\"login:0\") compadd -- ${(f)\"$(gator __complete users", "\"unfollow:0\")", "'browse:Show recent", "\"feed rename:0\")"},
+		"fish": {"'__gator_at \"login\" 0' -a '(gator __complete users", "'__gator_at \"agg\" 0' -a '10s 30s 1m", "'__gator_in \"agg\"' -l workers", "'__gator_at \"feed delete\" 0'"},
+	}
+	for shell, want := range wants {
+		var buf bytes.Buffer
+		if err := cmds.writeCompletion(&buf, shell); err != nil {
+			t.Fatalf("writeCompletion(%s) error: %v", shell, err)
+		}
+		script := buf.String()
+		for _, w := range want {
+			if !strings.Contains(script, w) {
+				t.Errorf("%s script missing %q", shell, w)
+			}
+		}
+		if strings.Contains(script, "__complete durations") {
+			t.Errorf("%s script looks up static durations through the database", shell)
+		}
+		if strings.Contains(script, "__complete:") || strings.Contains(script, "-a __complete") {
+			t.Errorf("%s script includes the hidden __complete command", shell)
+		}
+	}
+	if !cmds.Specs["__complete"].SkipMigrations {
+		t.Error("__complete migrates the database on every Tab press")
+	}
+	if err := cmds.writeCompletion(io.Discard, "powershell"); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
+
+func TestScrollTop(t *testing.T) {
+	tests := []struct{ top, selected, height, want int }{
+		{0, 3, 10, 0},
+		{0, 12, 10, 3},
+		{5, 2, 10, 2},
+		{5, 14, 10, 5},
+	}
+	for _, tt := range tests {
+		if got := scrollTop(tt.top, tt.selected, tt.height); got != tt.want {
+			t.Errorf("scrollTop(%d, %d, %d) = %d, want %d", tt.top, tt.selected, tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	tests := map[string]string{
+		"security":       "security",
+		"  go ":          "go",
+		"Tech / Go":      "Tech/Go",
+		"infra/k8s/helm": "infra/k8s/helm",
+	}
+	for in, want := range tests {
+		got, err := normalizeTag(in)
+		if err != nil || got != want {
+			t.Errorf("normalizeTag(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "  ", "go/", "/go"} {
+		if _, err := normalizeTag(in); err == nil {
+			t.Errorf("normalizeTag(%q): expected error", in)
+		}
+	}
+}
+
+func TestAskConfirmation(t *testing.T) {
+	for answer, want := range map[string]bool{"yes\n": true, " YES \n": true, "y\n": false, "\n": false, "": false} {
+		var out bytes.Buffer
+		if got := askConfirmation(strings.NewReader(answer), &out, "delete everything"); got != want {
+			t.Errorf("askConfirmation(%q) = %v, want %v", answer, got, want)
+		}
+		if !strings.Contains(out.String(), "This will delete everything.") {
+			t.Errorf("prompt: got %q", out.String())
+		}
+	}
+}
+
+func TestResetRejectsConflictingModes(t *testing.T) {
+	cmds := DefaultCommands()
+	err := cmds.Run(&State{}, Command{Name: "reset", Args: []string{"--posts-only", "--feeds-only", "--yes"}})
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("Run(reset --posts-only --feeds-only) = %v", err)
+	}
+}
@@ -0,0 +1,468 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Completion says how a shell should complete a positional argument
+type Completion string
+
+const (
+	CompleteNone      Completion = ""
+	CompleteUsers     Completion = "users"
+	CompleteFeeds     Completion = "feeds"
+	CompleteDurations Completion = "durations"
+	CompleteCommands  Completion = "commands"
+	CompleteFiles     Completion = "files"
+)
+
+// completionDurations are the intervals suggested for agg
+var completionDurations = []string{"10s", "30s", "1m", "5m", "15m", "30m", "1h"}
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+var outputFormats = []string{string(OutputText), string(OutputTable), string(OutputJSON), string(OutputCSV)}
+
+// completion command: prints a completion script for the given shell
+func (c *Commands) handlerCompletion(s *State, cmd Command) error {
+	return c.writeCompletion(os.Stdout, cmd.Args[0])
+}
+
+func (c *Commands) writeCompletion(w io.Writer, shell string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = c.bashCompletion()
+	case "zsh":
+		script = c.zshCompletion()
+	case "fish":
+		script = c.fishCompletion()
+	default:
+		return fmt.Errorf("unsupported shell %q (want %s)", shell, strings.Join(completionShells, ", "))
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// __complete command: prints the candidates for a dynamic completion, one
+// per line. Completion scripts call it and discard its errors.
+func handlerComplete(s *State, cmd Command) error {
+	var values []string
+	switch Completion(cmd.Args[0]) {
+	case CompleteUsers:
+		users, err := s.Db.GetUsers(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to get users: %v", err)
+		}
+		for _, u := range users {
+			values = append(values, u.Name)
+		}
+	case CompleteFeeds:
+		feeds, err := s.Db.GetFeedsWithUser(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to get feeds: %v", err)
+		}
+		for _, f := range feeds {
+			values = append(values, f.Url)
+		}
+	default:
+		return fmt.Errorf("unknown completion %q", cmd.Args[0])
+	}
+	for _, v := range values {
+		fmt.Println(v)
+	}
+	return nil
+}
+
+// completionSpec is the shell-independent view of a command used to generate
+// completion scripts
+type completionSpec struct {
+	name        string
+	description string
+	flags       []string // flag names without dashes, including help
+	valueFlags  []string // flags that take a value
+	args        []Arg
+	// sub is set for subcommands, whose name includes the parent's, e.g.
+	// "feed show"
+	sub bool
+}
+
+func (c *Commands) completionSpecs() []completionSpec {
+	var specs []completionSpec
+	for _, spec := range c.sortedSpecs() {
+		if spec.Hidden {
+			continue
+		}
+		specs = append(specs, newCompletionSpec(spec, false))
+		for _, sub := range spec.Subcommands {
+			if !sub.Hidden {
+				specs = append(specs, newCompletionSpec(sub, true))
+			}
+		}
+	}
+	return specs
+}
+
+func newCompletionSpec(spec Spec, sub bool) completionSpec {
+	cs := completionSpec{name: spec.Name, description: spec.Description, args: spec.Args, sub: sub}
+	spec.flagSet().VisitAll(func(f *flag.Flag) {
+		cs.flags = append(cs.flags, f.Name)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+			cs.valueFlags = append(cs.valueFlags, f.Name)
+		}
+	})
+	cs.flags = append(cs.flags, "help")
+	return cs
+}
+
+// commandNames returns the names of the top-level commands
+func (c *Commands) commandNames() []string {
+	var names []string
+	for _, cs := range c.completionSpecs() {
+		if !cs.sub {
+			names = append(names, cs.name)
+		}
+	}
+	return names
+}
+
+// parentNames returns the names of the commands that have subcommands, which
+// the completion scripts fold into the command name, e.g. "feed show"
+func (c *Commands) parentNames() string {
+	var names []string
+	for _, spec := range c.sortedSpecs() {
+		if !spec.Hidden && len(spec.Subcommands) > 0 {
+			names = append(names, spec.Name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func dashed(flags []string) string {
+	out := make([]string, len(flags))
+	for i, f := range flags {
+		out[i] = "--" + f
+	}
+	return strings.Join(out, " ")
+}
+
+// argCompletion returns the shell words completing the positional argument
+// with the given completion, using values for dynamic completions
+func (c *Commands) argCompletion(arg Arg, dynamic func(Completion) string, files string) string {
+	if len(arg.Choices) > 0 {
+		return "words:" + strings.Join(arg.Choices, " ")
+	}
+	switch arg.Complete {
+	case CompleteCommands:
+		return "words:" + strings.Join(c.commandNames(), " ")
+	case CompleteDurations:
+		return "words:" + strings.Join(completionDurations, " ")
+	case CompleteFiles:
+		return files
+	case CompleteNone:
+		return ""
+	}
+	return dynamic(arg.Complete)
+}
+
+func (c *Commands) bashCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# bash completion for gator; load with: source <(gator completion bash)
+
+_gator_flags() {
+    case "$1" in
+`)
+	for _, cs := range c.completionSpecs() {
+		fmt.Fprintf(&b, "    \"%s\") echo \"%s\" ;;\n", cs.name, dashed(cs.flags))
+	}
+	b.WriteString(`    esac
+}
+
+_gator_value_flag() {
+    local name="${2#-}"
+    name="${name#-}"
+    case "$1:$name" in
+`)
+	for _, cs := range c.completionSpecs() {
+		for _, f := range cs.valueFlags {
+			fmt.Fprintf(&b, "    \"%s:%s\") return 0 ;;\n", cs.name, f)
+		}
+	}
+	b.WriteString(`    esac
+    return 1
+}
+
+_gator() {
+    # Split the line on whitespace only: COMP_WORDS also breaks at ':', which
+    # would cut feed URLs into several words
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words
+    read -ra words <<< "$line"
+    [[ "$line" == *[[:space:]] ]] && words+=("")
+    local cword=$((${#words[@]} - 1))
+    local cur="${words[cword]}"
+    local prev="${words[cword-1]}"
+    local cmd="" npos=0 skip=0 i word
+    for ((i = 1; i < cword; i++)); do
+        word="${words[i]}"
+        if ((skip)); then
+            skip=0
+            continue
+        fi
+        case "$word" in
+        -o|-output|--output) skip=1; continue ;;
+        -*=*) continue ;;
+        esac
+        if [[ -z "$cmd" ]]; then
+            [[ "$word" == -* ]] || cmd="$word"
+        elif [[ "$word" == -* ]]; then
+            _gator_value_flag "$cmd" "$word" && skip=1
+        elif ((npos == 0)) && [[ " ` + c.parentNames() + ` " == *" $cmd "* ]]; then
+            cmd="$cmd $word"
+        else
+            ((npos++))
+        fi
+    done
+
+    case "$prev" in
+    -o|-output|--output)
+        COMPREPLY=($(compgen -W "` + strings.Join(outputFormats, " ") + `" -- "$cur"))
+        return
+        ;;
+    esac
+    if ((skip)); then
+        return
+    fi
+    if [[ -z "$cmd" ]]; then
+        COMPREPLY=($(compgen -W "` + strings.Join(c.commandNames(), " ") + ` --output" -- "$cur"))
+        return
+    fi
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "$(_gator_flags "$cmd") --output" -- "$cur"))
+        return
+    fi
+    case "$cmd:$npos" in
+`)
+	dynamic := func(kind Completion) string {
+		return fmt.Sprintf("COMPREPLY=($(compgen -W \"$(gator __complete %s 2>/dev/null)\" -- \"$cur\"))", kind)
+	}
+	for _, cs := range c.completionSpecs() {
+		for i, arg := range cs.args {
+			action := c.argCompletion(arg, dynamic, `COMPREPLY=($(compgen -f -- "$cur"))`)
+			if words, ok := strings.CutPrefix(action, "words:"); ok {
+				action = fmt.Sprintf("COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))", words)
+			}
+			if action != "" {
+				fmt.Fprintf(&b, "    \"%s:%d\") %s ;;\n", cs.name, i, action)
+			}
+		}
+	}
+	b.WriteString(`    esac
+    # Readline only replaces the text after the last ':', so drop the part of
+    # each candidate that is already on the command line
+    if [[ "$cur" == *:* && "$COMP_WORDBREAKS" == *:* ]]; then
+        local colon_prefix="${cur%"${cur##*:}"}"
+        COMPREPLY=("${COMPREPLY[@]#"$colon_prefix"}")
+    fi
+}
+
+complete -F _gator gator
+`)
+	return b.String()
+}
+
+func (c *Commands) zshCompletion() string {
+	var b strings.Builder
+	b.WriteString(`#compdef gator
+# zsh completion for gator; load with: source <(gator completion zsh)
+
+_gator() {
+    local cmd="" npos=0 skip=0 i word name
+    local -a cmds flags
+    cmds=(
+`)
+	for _, cs := range c.completionSpecs() {
+		if !cs.sub {
+			fmt.Fprintf(&b, "        '%s:%s'\n", cs.name, zshQuote(cs.description))
+		}
+	}
+	b.WriteString(`    )
+    for ((i = 2; i < CURRENT; i++)); do
+        word="${words[i]}"
+        if ((skip)); then
+            skip=0
+            continue
+        fi
+        case "$word" in
+        -o|-output|--output) skip=1; continue ;;
+        -*=*) continue ;;
+        esac
+        if [[ -z "$cmd" ]]; then
+            [[ "$word" == -* ]] || cmd="$word"
+        elif [[ "$word" == -* ]]; then
+            name="${word#-}"
+            name="${name#-}"
+            case "$cmd:$name" in
+`)
+	for _, cs := range c.completionSpecs() {
+		for _, f := range cs.valueFlags {
+			fmt.Fprintf(&b, "            \"%s:%s\") skip=1 ;;\n", cs.name, f)
+		}
+	}
+	b.WriteString(`            esac
+        elif ((npos == 0)) && [[ " ` + c.parentNames() + ` " == *" $cmd "* ]]; then
+            cmd="$cmd $word"
+        else
+            ((npos++))
+        fi
+    done
+
+    case "${words[CURRENT-1]}" in
+    -o|-output|--output)
+        compadd -- ` + strings.Join(outputFormats, " ") + `
+        return
+        ;;
+    esac
+    if ((skip)); then
+        return
+    fi
+    if [[ -z "$cmd" ]]; then
+        _describe 'command' cmds
+        return
+    fi
+    if [[ "$PREFIX" == -* ]]; then
+        case "$cmd" in
+`)
+	for _, cs := range c.completionSpecs() {
+		fmt.Fprintf(&b, "        \"%s\") flags=(%s --output) ;;\n", cs.name, dashed(cs.flags))
+	}
+	b.WriteString(`        esac
+        compadd -- $flags
+        return
+    fi
+    case "$cmd:$npos" in
+`)
+	dynamic := func(kind Completion) string {
+		return fmt.Sprintf("compadd -- ${(f)\"$(gator __complete %s 2>/dev/null)\"}", kind)
+	}
+	for _, cs := range c.completionSpecs() {
+		for i, arg := range cs.args {
+			action := c.argCompletion(arg, dynamic, "_files")
+			if words, ok := strings.CutPrefix(action, "words:"); ok {
+				action = "compadd -- " + words
+			}
+			if action != "" {
+				fmt.Fprintf(&b, "    \"%s:%d\") %s ;;\n", cs.name, i, action)
+			}
+		}
+	}
+	b.WriteString(`    esac
+}
+
+compdef _gator gator
+`)
+	return b.String()
+}
+
+func (c *Commands) fishCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# fish completion for gator; load with: gator completion fish | source
+
+# prints the command name and the number of positional arguments before the
+# cursor, skipping flags and their values
+function __gator_position
+    set -l cmd
+    set -l npos 0
+    set -l skip 0
+    for word in (commandline -opc)[2..-1]
+        if test $skip -eq 1
+            set skip 0
+            continue
+        end
+        switch $word
+            case -o -output --output
+                set skip 1
+                continue
+            case '-*=*'
+                continue
+        end
+        if test -z "$cmd"
+            string match -q -- '-*' $word; or set cmd $word
+        else if string match -q -- '-*' $word
+            set -l name (string replace -r '^--?' '' -- $word)
+            switch "$cmd:$name"
+                case `)
+	var valueFlags []string
+	for _, cs := range c.completionSpecs() {
+		for _, f := range cs.valueFlags {
+			valueFlags = append(valueFlags, fmt.Sprintf("'%s:%s'", cs.name, f))
+		}
+	}
+	b.WriteString(strings.Join(valueFlags, " "))
+	b.WriteString(`
+                    set skip 1
+            end
+        else if test $npos -eq 0; and contains -- "$cmd" ` + c.parentNames() + `
+            set cmd "$cmd $word"
+        else
+            set npos (math $npos + 1)
+        end
+    end
+    if test $skip -eq 1
+        set npos -1
+    end
+    echo "$cmd:$npos"
+end
+
+function __gator_at
+    test (__gator_position) = "$argv[1]:$argv[2]"
+end
+
+function __gator_in
+    string match -q -- "$argv[1]:*" (__gator_position)
+end
+
+complete -c gator -f
+complete -c gator -s o -l output -x -a '` + strings.Join(outputFormats, " ") + `' -d 'Output format'
+`)
+	for _, cs := range c.completionSpecs() {
+		if !cs.sub {
+			fmt.Fprintf(&b, "complete -c gator -n __fish_use_subcommand -a %s -d '%s'\n", cs.name, fishQuote(cs.description))
+		}
+	}
+	for _, cs := range c.completionSpecs() {
+		for _, f := range cs.flags {
+			fmt.Fprintf(&b, "complete -c gator -n '__gator_in \"%s\"' -l %s\n", cs.name, f)
+		}
+	}
+	dynamic := func(kind Completion) string {
+		return fmt.Sprintf("-a '(gator __complete %s 2>/dev/null)'", kind)
+	}
+	for _, cs := range c.completionSpecs() {
+		for i, arg := range cs.args {
+			action := c.argCompletion(arg, dynamic, "-F")
+			if words, ok := strings.CutPrefix(action, "words:"); ok {
+				action = fmt.Sprintf("-a '%s'", words)
+			}
+			if action != "" {
+				fmt.Fprintf(&b, "complete -c gator -n '__gator_at \"%s\" %d' %s\n", cs.name, i, action)
+			}
+		}
+	}
+	return b.String()
+}
+
+func zshQuote(s string) string {
+	s = strings.ReplaceAll(s, "'", `'\''`)
+	return strings.ReplaceAll(s, ":", `\:`)
+}
+
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "'", `\'`)
+}
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"aggreGATOR/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type feedDetailsRecord struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	ChannelTitle  *string    `json:"channel_title"`
+	CreatedBy     string     `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Followers     int64      `json:"followers"`
+	Posts         int64      `json:"posts"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	LastSuccessAt *time.Time `json:"last_success_at"`
+	FailureCount  int32      `json:"failure_count"`
+	LastError     *string    `json:"last_error"`
+	DisabledAt    *time.Time `json:"disabled_at"`
+}
+
+// getFeedDetails looks up a feed by URL along with its creator and counts
+func getFeedDetails(s *State, url string) (database.GetFeedDetailsRow, error) {
+	feed, err := s.Db.GetFeedDetails(context.Background(), url)
+	if errors.Is(err, sql.ErrNoRows) {
+		return feed, fmt.Errorf("no feed with url %s", url)
+	}
+	if err != nil {
+		return feed, fmt.Errorf("failed to get feed: %v", err)
+	}
+	return feed, nil
+}
+
+// ownedFeed looks up a feed by URL and checks that user created it; only the
+// creator may rename, move or delete a feed
+func ownedFeed(s *State, user database.User, url string) (database.GetFeedDetailsRow, error) {
+	feed, err := getFeedDetails(s, url)
+	if err != nil {
+		return feed, err
+	}
+	if feed.UserID != user.ID {
+		return feed, fmt.Errorf("feed %s was added by %s; only they can change it", url, feed.UserName)
+	}
+	return feed, nil
+}
+
+// feed show: prints everything known about one feed
+func handlerFeedShow(s *State, cmd Command) error {
+	feed, err := getFeedDetails(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if s.Output.structured() {
+		return writeRecords(os.Stdout, s.Output, []feedDetailsRecord{{
+			ID:            feed.ID,
+			Name:          feed.Name,
+			URL:           feed.Url,
+			ChannelTitle:  nullStringPtr(feed.ChannelTitle),
+			CreatedBy:     feed.UserName,
+			CreatedAt:     feed.CreatedAt,
+			Followers:     feed.FollowerCount,
+			Posts:         feed.PostCount,
+			LastFetchedAt: nullTimePtr(feed.LastFetchedAt),
+			LastSuccessAt: nullTimePtr(feed.LastSuccessAt),
+			FailureCount:  feed.FailureCount,
+			LastError:     nullStringPtr(feed.LastError),
+			DisabledAt:    nullTimePtr(feed.DisabledAt),
+		}})
+	}
+	fmt.Printf("%s\n  url: %s\n", feed.Name, feed.Url)
+	if feed.ChannelTitle.Valid && feed.ChannelTitle.String != feed.Name {
+		fmt.Printf("  channel title: %s\n", feed.ChannelTitle.String)
+	}
+	fmt.Printf("  created by: %s on %s\n", feed.UserName, feed.CreatedAt.Format(time.RFC3339))
+	fmt.Printf("  followers: %d\n  posts: %d\n", feed.FollowerCount, feed.PostCount)
+	fmt.Printf("  last fetched: %s\n  last success: %s\n", formatNullTime(feed.LastFetchedAt), formatNullTime(feed.LastSuccessAt))
+	if feed.FailureCount > 0 {
+		fmt.Printf("  failures: %d\n", feed.FailureCount)
+	}
+	if feed.LastError.Valid {
+		fmt.Printf("  last error: %s\n", feed.LastError.String)
+	}
+	if feed.DisabledAt.Valid {
+		fmt.Printf("  disabled: %s\n", formatNullTime(feed.DisabledAt))
+	}
+	return nil
+}
+
+// feed rename: changes the shared name of a feed the user created
+func handlerFeedRename(s *State, cmd Command, user database.User) error {
+	name := strings.TrimSpace(cmd.Args[1])
+	if name == "" {
+		return fmt.Errorf("feed name cannot be empty")
+	}
+	feed, err := ownedFeed(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	err = s.Db.UpdateFeedName(context.Background(), database.UpdateFeedNameParams{ID: feed.ID, Name: name})
+	if err != nil {
+		return fmt.Errorf("failed to rename feed: %v", err)
+	}
+	fmt.Printf("Renamed feed '%s' to '%s'\n", feed.Name, name)
+	return nil
+}
+
+// feed set-url: moves a feed the user created to a new URL. The feed keeps
+// its ID, so its posts, followers, tags and read state carry over.
+func handlerFeedSetURL(s *State, cmd Command, user database.User) error {
+	newURL := strings.TrimSpace(cmd.Args[1])
+	if newURL == "" {
+		return fmt.Errorf("feed url cannot be empty")
+	}
+	feed, err := ownedFeed(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if newURL == feed.Url {
+		return fmt.Errorf("feed already has url %s", newURL)
+	}
+	existing, err := s.Db.GetFeedByUrl(context.Background(), newURL)
+	if err == nil {
+		return fmt.Errorf("feed '%s' already has url %s", existing.Name, newURL)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check for duplicate feed: %v", err)
+	}
+	err = s.Db.UpdateFeedUrl(context.Background(), database.UpdateFeedUrlParams{ID: feed.ID, Url: newURL})
+	if isUniqueViolation(err) {
+		return fmt.Errorf("another feed already has url %s", newURL)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update feed url: %v", err)
+	}
+	fmt.Printf("Moved feed '%s' from %s to %s (%d posts kept)\n", feed.Name, feed.Url, newURL, feed.PostCount)
+	return nil
+}
+
+// feed delete: removes a feed the user created, with its posts and follows
+func handlerFeedDelete(s *State, cmd Command, user database.User) error {
+	feed, err := ownedFeed(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	action := fmt.Sprintf("delete feed '%s' and its %d post(s), including stars, for all %d follower(s)", feed.Name, feed.PostCount, feed.FollowerCount)
+	if err := confirm(cmd, action); err != nil {
+		return err
+	}
+	if err := s.Db.DeleteFeed(context.Background(), feed.ID); err != nil {
+		return fmt.Errorf("failed to delete feed: %v", err)
+	}
+	fmt.Printf("Deleted feed '%s' with %d posts and %d followers\n", feed.Name, feed.PostCount, feed.FollowerCount)
+	return nil
+}
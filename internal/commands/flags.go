@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"flag"
+	"io"
+)
+
+// newFlagSet returns a flag set for a command that reports parse errors to
+// the caller instead of printing them
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseFlags parses args into fs, allowing flags and positional arguments to
+// be interleaved (e.g. "agg 1m --workers 8"), and returns the positional
+// arguments in order. Everything after "--" is positional, so arguments that
+// start with a dash can be passed, e.g. "search -- go -generics".
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// PrintUsage writes the list of registered commands to w
+func (c *Commands) PrintUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gator [--output text|table|json|csv] <command> [args...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, spec := range c.sortedSpecs() {
+		if spec.Hidden {
+			continue
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", spec.Usage(), spec.Description)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'gator help <command>' for details on a command.")
+}
+
+// writeCommandHelp writes the usage, arguments and flags of a command to w
+func writeCommandHelp(w io.Writer, spec Spec) {
+	fmt.Fprintf(w, "Usage: gator %s\n\n%s\n", spec.Usage(), spec.Description)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(spec.Subcommands) > 0 {
+		fmt.Fprintln(tw, "\nSubcommands:")
+		for _, sub := range spec.Subcommands {
+			fmt.Fprintf(tw, "  %s\t%s\n", sub.Usage(), sub.Description)
+		}
+	} else if len(spec.Args) > 0 {
+		fmt.Fprintln(tw, "\nArguments:")
+		for _, arg := range spec.Args {
+			fmt.Fprintf(tw, "  %s\t%s\n", arg.Name, arg.Description)
+		}
+	}
+	if spec.hasFlags() {
+		fmt.Fprintln(tw, "\nFlags:")
+		spec.flagSet().VisitAll(func(f *flag.Flag) {
+			name, usage := flag.UnquoteUsage(f)
+			if name != "" {
+				name = " " + name
+			}
+			def := ""
+			if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+				def = fmt.Sprintf(" (default %s)", f.DefValue)
+			}
+			fmt.Fprintf(tw, "  --%s%s\t%s%s\n", f.Name, name, usage, def)
+		})
+	}
+	tw.Flush()
+}
+
+func printCommandHelp(spec Spec) {
+	writeCommandHelp(os.Stdout, spec)
+}
+
+// help command: lists every command, or describes one in detail
+func (c *Commands) handlerHelp(s *State, cmd Command) error {
+	if len(cmd.Args) == 0 {
+		c.PrintUsage(os.Stdout)
+		return nil
+	}
+	name := cmd.Args[0]
+	spec, ok := c.Specs[name]
+	if !ok {
+		return fmt.Errorf("unknown command: %s", name)
+	}
+	if len(cmd.Args) > 1 {
+		if spec, ok = spec.subcommand(cmd.Args[1]); !ok {
+			return fmt.Errorf("unknown %s subcommand: %s", name, cmd.Args[1])
+		}
+	}
+	printCommandHelp(spec)
+	return nil
+}
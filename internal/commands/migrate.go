@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"aggreGATOR/internal/migrate"
+	"aggreGATOR/sql/schema"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+type migrationRecord struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+func newMigrator(s *State) (*migrate.Migrator, error) {
+	migrations, err := migrate.Load(schema.FS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %v", err)
+	}
+	return migrate.New(s.Conn, migrations), nil
+}
+
+// MigrateDatabase applies any pending migrations, reporting each one on
+// stderr. It fails with migrate.ErrSchemaTooNew if the database was migrated
+// by a newer gator.
+func MigrateDatabase(s *State) error {
+	migrator, err := newMigrator(s)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(context.Background())
+	for _, m := range applied {
+		fmt.Fprintf(os.Stderr, "Applied migration %s\n", m)
+	}
+	return err
+}
+
+// migrate command: applies or rolls back schema migrations, or lists them
+func handlerMigrate(s *State, cmd Command) error {
+	migrator, err := newMigrator(s)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	switch cmd.Args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied migration %s\n", m)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("Database is up to date.")
+		}
+		return err
+	case "down":
+		m, ok, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("No migrations to roll back.")
+			return nil
+		}
+		fmt.Printf("Rolled back migration %s\n", m)
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil && !errors.Is(err, migrate.ErrSchemaTooNew) {
+			return err
+		}
+		if s.Output.structured() {
+			records := make([]migrationRecord, len(statuses))
+			for i, st := range statuses {
+				records[i] = migrationRecord{Version: st.Version, Name: st.Name, Applied: st.AppliedAt.Valid, AppliedAt: nullTimePtr(st.AppliedAt)}
+			}
+			if werr := writeRecords(os.Stdout, s.Output, records); werr != nil {
+				return werr
+			}
+			return err
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.AppliedAt.Valid {
+				state = "applied " + st.AppliedAt.Time.Format(time.RFC3339)
+			}
+			fmt.Printf("%-36s %s\n", st.Migration, state)
+		}
+		return err
+	}
+	return fmt.Errorf("unknown migrate subcommand %q (want up, down or status)", cmd.Args[0])
+}
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"aggreGATOR/internal/database"
+	"aggreGATOR/internal/opml"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// import-opml command: creates and follows every feed listed in an OPML file,
+// printing what happened to each one
+func handlerImportOPML(s *State, cmd Command, user database.User) error {
+	file, err := os.Open(cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	doc, err := opml.Parse(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse OPML: %v", err)
+	}
+
+	follows, err := s.Db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get feed follows: %v", err)
+	}
+	following := make(map[uuid.UUID]bool)
+	for _, f := range follows {
+		following[f.FeedID] = true
+	}
+
+	entries := doc.Feeds()
+	seen := make(map[string]bool)
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		status, err := importFeed(s, user, entry, seen, following)
+		if err == nil {
+			err = tagImportedFeed(s, user, entry)
+		}
+		if err != nil {
+			status = "failed"
+			fmt.Printf("%-9s %s (%s): %v\n", status, entry.Title, entry.URL, err)
+		} else {
+			fmt.Printf("%-9s %s (%s)\n", status, entry.Title, entry.URL)
+		}
+		counts[status]++
+	}
+	fmt.Printf("Imported %d feed(s): %d created, %d followed, %d skipped, %d failed\n",
+		len(entries), counts["created"], counts["followed"], counts["skipped"], counts["failed"])
+	if counts["failed"] > 0 {
+		return fmt.Errorf("%d feed(s) could not be imported", counts["failed"])
+	}
+	return nil
+}
+
+// importFeed creates the feed if nobody has added it yet and follows it,
+// returning "created", "followed" or "skipped"
+func importFeed(s *State, user database.User, entry opml.Feed, seen map[string]bool, following map[uuid.UUID]bool) (string, error) {
+	if seen[entry.URL] {
+		return "skipped", nil
+	}
+	seen[entry.URL] = true
+
+	status := "followed"
+	feed, err := s.Db.GetFeedByUrl(context.Background(), entry.URL)
+	if errors.Is(err, sql.ErrNoRows) {
+		name := entry.Title
+		if name == "" {
+			name = entry.URL
+		}
+		feed, err = s.Db.CreateFeed(context.Background(), database.CreateFeedParams{
+			Name:   name,
+			Url:    entry.URL,
+			UserID: user.ID,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create feed: %v", err)
+		}
+		status = "created"
+	} else if err != nil {
+		return "", fmt.Errorf("could not look up feed: %v", err)
+	}
+	if following[feed.ID] {
+		return "skipped", nil
+	}
+
+	now := time.Now().UTC()
+	_, err = s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create feed follow: %v", err)
+	}
+	following[feed.ID] = true
+	return status, nil
+}
+
+// tagImportedFeed tags the user's follow of a feed with the OPML folder it
+// was listed in, if any
+func tagImportedFeed(s *State, user database.User, entry opml.Feed) error {
+	if len(entry.Folders) == 0 {
+		return nil
+	}
+	tag, err := normalizeTag(strings.Join(entry.Folders, "/"))
+	if err != nil {
+		return err
+	}
+	feed, err := s.Db.GetFeedByUrl(context.Background(), entry.URL)
+	if err != nil {
+		return fmt.Errorf("could not look up feed: %v", err)
+	}
+	return tagFollow(s, user, feed.ID, tag)
+}
+
+// export-opml command: writes the current user's followed feeds, or every
+// feed with --all, as an OPML 2.0 document to stdout or the given file
+func handlerExportOPML(s *State, cmd Command, user database.User) error {
+	all := cmd.Bool("all")
+
+	var feeds []opml.Feed
+	// count is the number of distinct feeds, which can be fewer than the
+	// outlines written when feeds have several tags
+	var count int
+	title := fmt.Sprintf("%s's subscriptions", user.Name)
+	if all {
+		rows, err := s.Db.GetFeedsWithUser(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to get feeds: %v", err)
+		}
+		for _, f := range rows {
+			feeds = append(feeds, opml.Feed{Title: f.Name, URL: f.Url})
+		}
+		count = len(rows)
+		title = "All gator feeds"
+	} else {
+		follows, err := s.Db.GetFeedFollowsForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get feed follows: %v", err)
+		}
+		tags, err := followTags(s, user)
+		if err != nil {
+			return err
+		}
+		// A feed appears once in the folder for each of its tags, or at the
+		// top level when it has none
+		count = len(follows)
+		for _, f := range follows {
+			if len(tags[f.FeedID]) == 0 {
+				feeds = append(feeds, opml.Feed{Title: f.FeedName, URL: f.FeedUrl})
+			}
+			for _, tag := range tags[f.FeedID] {
+				feeds = append(feeds, opml.Feed{Title: f.FeedName, URL: f.FeedUrl, Folders: strings.Split(tag, "/")})
+			}
+		}
+	}
+
+	if len(cmd.Args) == 0 {
+		if err := opml.New(title, feeds).Write(os.Stdout); err != nil {
+			return fmt.Errorf("failed to write OPML: %v", err)
+		}
+		return nil
+	}
+	file, err := os.Create(cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if err := opml.New(title, feeds).Write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write OPML: %v", err)
+	}
+	// A failed close can mean the file was not fully written
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write OPML: %v", err)
+	}
+	fmt.Printf("Exported %d feed(s) to %s\n", count, cmd.Args[0])
+	return nil
+}
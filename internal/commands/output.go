@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+)
+
+// OutputFormat selects how listing commands print their results
+type OutputFormat string
+
+const (
+	// OutputText is the default human-oriented output of each command
+	OutputText  OutputFormat = "text"
+	OutputTable OutputFormat = "table"
+	OutputJSON  OutputFormat = "json"
+	OutputCSV   OutputFormat = "csv"
+)
+
+// maxTableCell is the width at which table cells are truncated
+const maxTableCell = 60
+
+// ParseOutputFormat validates an --output value
+func ParseOutputFormat(value string) (OutputFormat, error) {
+	switch f := OutputFormat(strings.ToLower(value)); f {
+	case OutputText, OutputTable, OutputJSON, OutputCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q (want text, table, json or csv)", value)
+	}
+}
+
+// ExtractOutputFlag removes the global --output/-o option given before the
+// command name. After the command name the option is parsed with the
+// command's own flags, so positional arguments are never mistaken for it.
+func ExtractOutputFlag(args []string) (OutputFormat, []string, error) {
+	format := OutputText
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		var value string
+		switch {
+		case arg == "--output" || arg == "-output" || arg == "-o":
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("%s requires a value (text, table, json or csv)", arg)
+			}
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "--output="), strings.HasPrefix(arg, "-output="), strings.HasPrefix(arg, "-o="):
+			value = arg[strings.Index(arg, "=")+1:]
+		default:
+			return format, args[i:], nil
+		}
+		f, err := ParseOutputFormat(value)
+		if err != nil {
+			return "", nil, err
+		}
+		format = f
+	}
+	return format, args[i:], nil
+}
+
+// outputFlagNames are the names of the --output option accepted after the
+// command name
+var outputFlagNames = []string{"output", "o"}
+
+// outputFormat returns the format given with --output/-o after the command
+// name, or def when the option wasn't given
+func (cmd Command) outputFormat(def OutputFormat) (OutputFormat, error) {
+	format := def
+	for _, name := range outputFlagNames {
+		if value := cmd.String(name); value != "" {
+			f, err := ParseOutputFormat(value)
+			if err != nil {
+				return "", err
+			}
+			format = f
+		}
+	}
+	return format, nil
+}
+
+// structured reports whether results should go through writeRecords rather
+// than a command's own text output
+func (f OutputFormat) structured() bool {
+	return f == OutputTable || f == OutputJSON || f == OutputCSV
+}
+
+// writeRecords renders records, a slice of structs, as JSON, CSV or an
+// aligned table. Column names come from the fields' json tags; fields tagged
+// `table:"-"` are left out of tables.
+func writeRecords(w io.Writer, format OutputFormat, records any) error {
+	v := reflect.ValueOf(records)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("writeRecords: expected a slice, got %T", records)
+	}
+	if format == OutputJSON {
+		if v.IsNil() {
+			records = []struct{}{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+
+	fields := recordFields(v.Type().Elem(), format == OutputTable)
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.name
+	}
+	rows := make([][]string, v.Len())
+	for i := range rows {
+		row := make([]string, len(fields))
+		for j, f := range fields {
+			row[j] = formatCell(v.Index(i).Field(f.index))
+		}
+		rows[i] = row
+	}
+
+	if format == OutputCSV {
+		writer := csv.NewWriter(w)
+		writer.Write(header)
+		writer.WriteAll(rows)
+		return writer.Error()
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, name := range header {
+		header[i] = strings.ToUpper(name)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = tableCell(cell)
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+type recordField struct {
+	name  string
+	index int
+}
+
+func recordFields(t reflect.Type, table bool) []recordField {
+	var fields []recordField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" || (table && f.Tag.Get("table") == "-") {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, recordField{name: name, index: i})
+	}
+	return fields
+}
+
+// formatCell converts a record field to text; nil pointers become empty cells
+func formatCell(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	if list, ok := v.Interface().([]string); ok {
+		return strings.Join(list, ", ")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// tableCell keeps a cell on one line and within maxTableCell characters
+func tableCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= maxTableCell {
+		return s
+	}
+	return string([]rune(s)[:maxTableCell-3]) + "..."
+}
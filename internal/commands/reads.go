@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"aggreGATOR/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func handlerRead(s *State, cmd Command, user database.User) error {
+	post, err := findPost(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	err = s.Db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark post read: %v", err)
+	}
+	fmt.Printf("Marked '%s' as read\n", post.Title)
+	return nil
+}
+
+func handlerUnread(s *State, cmd Command, user database.User) error {
+	post, err := findPost(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	err = s.Db.MarkPostUnread(context.Background(), database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark post unread: %v", err)
+	}
+	fmt.Printf("Marked '%s' as unread\n", post.Title)
+	return nil
+}
+
+func handlerMarkAllRead(s *State, cmd Command, user database.User) error {
+	feedFilter := cmd.String("feed")
+	before := cmd.String("before")
+	params := database.MarkAllPostsReadParams{
+		ReadAt: time.Now().UTC(),
+		UserID: user.ID,
+	}
+	if feedFilter != "" {
+		feedID, err := resolveFollowedFeed(s, user, feedFilter)
+		if err != nil {
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feedID, Valid: true}
+	}
+	if before != "" {
+		loc, err := s.Cfg.Location()
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %v", s.Cfg.Timezone, err)
+		}
+		t, err := parseTimeBound(before, loc)
+		if err != nil {
+			return fmt.Errorf("invalid --before: %v", err)
+		}
+		params.Before = sql.NullTime{Time: t, Valid: true}
+	}
+	n, err := s.Db.MarkAllPostsRead(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("failed to mark posts read: %v", err)
+	}
+	fmt.Printf("Marked %d post(s) as read\n", n)
+	return nil
+}
+
+// findPost looks up a post by id or url
+func findPost(s *State, idOrURL string) (database.Post, error) {
+	var post database.Post
+	var err error
+	if id, parseErr := uuid.Parse(idOrURL); parseErr == nil {
+		post, err = s.Db.GetPostByID(context.Background(), id)
+	} else {
+		post, err = s.Db.GetPostByUrl(context.Background(), idOrURL)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Post{}, fmt.Errorf("no post with id or url %s", idOrURL)
+	}
+	if err != nil {
+		return database.Post{}, fmt.Errorf("could not look up post %s: %v", idOrURL, err)
+	}
+	return post, nil
+}
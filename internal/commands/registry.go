@@ -0,0 +1,229 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Arg describes a positional argument of a command
+type Arg struct {
+	Name        string
+	Description string
+	Optional    bool
+	// Variadic args collect every remaining positional argument and must be
+	// the last one declared
+	Variadic bool
+	// Complete and Choices tell shell completion what values to offer
+	Complete Completion
+	Choices  []string
+}
+
+// Spec describes a command: how it is invoked, what it does and the handler
+// that runs it. Flags declares the command's flags on the flag set that is
+// later attached to the Command passed to Handler.
+type Spec struct {
+	Name        string
+	Description string
+	Args        []Arg
+	Flags       func(fs *flag.FlagSet)
+	Handler     func(*State, Command) error
+	// Hidden commands are left out of help and shell completion
+	Hidden bool
+	// SkipMigrations commands run without first migrating the database,
+	// because they don't use it or manage the schema themselves
+	SkipMigrations bool
+	// Subcommands, when set, take the place of Args, Flags and Handler: the
+	// first argument names the subcommand that runs, e.g. "feed show <url>"
+	Subcommands []Spec
+}
+
+// Usage returns the one-line invocation of the command, e.g.
+// "browse [limit] [flags]"
+func (spec Spec) Usage() string {
+	parts := []string{spec.Name}
+	for _, arg := range spec.Args {
+		name := arg.Name
+		if arg.Variadic {
+			name += "..."
+		}
+		if arg.Optional {
+			name = "[" + name + "]"
+		} else {
+			name = "<" + name + ">"
+		}
+		parts = append(parts, name)
+	}
+	if spec.hasFlags() {
+		parts = append(parts, "[flags]")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (spec Spec) hasFlags() bool {
+	n := 0
+	spec.flagSet().VisitAll(func(*flag.Flag) { n++ })
+	return n > 0
+}
+
+// flagSet returns a fresh flag set with the command's flags declared on it
+func (spec Spec) flagSet() *flag.FlagSet {
+	fs := newFlagSet(spec.Name)
+	if spec.Flags != nil {
+		spec.Flags(fs)
+	}
+	return fs
+}
+
+// parse parses args against the command's flags and validates the number of
+// positional arguments
+func (spec Spec) parse(args []string) (Command, error) {
+	fs := spec.flagSet()
+	for _, name := range outputFlagNames {
+		fs.String(name, "", "output format")
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return Command{}, err
+	}
+	required, variadic := 0, false
+	for _, arg := range spec.Args {
+		if !arg.Optional {
+			required++
+		}
+		variadic = variadic || arg.Variadic
+	}
+	if len(positional) < required {
+		return Command{}, fmt.Errorf("%s requires %d argument(s), got %d\nusage: gator %s", spec.Name, required, len(positional), spec.Usage())
+	}
+	if !variadic && len(positional) > len(spec.Args) {
+		return Command{}, fmt.Errorf("%s takes at most %d argument(s), got %d\nusage: gator %s", spec.Name, len(spec.Args), len(positional), spec.Usage())
+	}
+	return Command{Name: spec.Name, Args: positional, flags: fs}, nil
+}
+
+// Add registers a command described by spec. Its arguments are parsed and
+// validated against the spec before the handler runs, and "-h"/"--help"
+// print the command's help instead of running it.
+func (c *Commands) Add(spec Spec) {
+	if c.Specs == nil {
+		c.Specs = make(map[string]Spec)
+	}
+	if len(spec.Subcommands) > 0 {
+		names := make([]string, len(spec.Subcommands))
+		for i := range spec.Subcommands {
+			names[i] = spec.Subcommands[i].Name
+			// Usage and errors show the full invocation, e.g. "feed show"
+			spec.Subcommands[i].Name = spec.Name + " " + names[i]
+		}
+		spec.Args = []Arg{{Name: "subcommand", Description: "one of " + strings.Join(names, ", "), Choices: names}}
+	}
+	c.Specs[spec.Name] = spec
+	c.Register(spec.Name, func(s *State, cmd Command) error {
+		return spec.run(s, cmd.Args)
+	})
+}
+
+func (spec Spec) run(s *State, args []string) error {
+	if len(spec.Subcommands) > 0 && len(args) > 0 {
+		if sub, ok := spec.subcommand(args[0]); ok {
+			return sub.run(s, args[1:])
+		}
+		if !strings.HasPrefix(args[0], "-") {
+			return fmt.Errorf("unknown %s subcommand: %s\nusage: gator %s", spec.Name, args[0], spec.Usage())
+		}
+	}
+	parsed, err := spec.parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		printCommandHelp(spec)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if spec.Handler == nil {
+		// Parent commands only run through their subcommands
+		return fmt.Errorf("missing or unknown %s subcommand\nusage: gator %s", spec.Name, spec.Usage())
+	}
+	if s.Output, err = parsed.outputFormat(s.Output); err != nil {
+		return err
+	}
+	if !spec.SkipMigrations && s.Migrate != nil {
+		if err := s.Migrate(); err != nil {
+			return fmt.Errorf("failed to migrate database: %v", err)
+		}
+	}
+	return spec.Handler(s, parsed)
+}
+
+// subcommand returns the subcommand with the given short name, e.g. "show"
+func (spec Spec) subcommand(name string) (Spec, bool) {
+	for _, sub := range spec.Subcommands {
+		if sub.Name == spec.Name+" "+name {
+			return sub, true
+		}
+	}
+	return Spec{}, false
+}
+
+// sortedSpecs returns the registered command specs ordered by name
+func (c *Commands) sortedSpecs() []Spec {
+	specs := make([]Spec, 0, len(c.Specs))
+	for _, spec := range c.Specs {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+func (cmd Command) lookup(name string) any {
+	if cmd.flags == nil {
+		return nil
+	}
+	f := cmd.flags.Lookup(name)
+	if f == nil {
+		return nil
+	}
+	return f.Value.(flag.Getter).Get()
+}
+
+// Bool returns the value of the named bool flag
+func (cmd Command) Bool(name string) bool {
+	v, _ := cmd.lookup(name).(bool)
+	return v
+}
+
+// String returns the value of the named string flag
+func (cmd Command) String(name string) string {
+	v, _ := cmd.lookup(name).(string)
+	return v
+}
+
+// Int returns the value of the named int flag
+func (cmd Command) Int(name string) int {
+	v, _ := cmd.lookup(name).(int)
+	return v
+}
+
+// Duration returns the value of the named duration flag
+func (cmd Command) Duration(name string) time.Duration {
+	v, _ := cmd.lookup(name).(time.Duration)
+	return v
+}
+
+// intArg parses the optional positional argument at index i as a positive
+// integer, returning def when it is absent
+func (cmd Command) intArg(i int, name string, def int) (int, error) {
+	if i >= len(cmd.Args) {
+		return def, nil
+	}
+	n, err := strconv.Atoi(cmd.Args[i])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", name, cmd.Args[i])
+	}
+	return n, nil
+}
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"aggreGATOR/internal/database"
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// confirm asks the user to type "yes" before a destructive action unless
+// --yes was given. Without a terminal to ask on, the action is refused.
+func confirm(cmd Command, action string) error {
+	if cmd.Bool("yes") {
+		return nil
+	}
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("refusing to %s without confirmation; pass --yes to proceed", action)
+	}
+	if !askConfirmation(os.Stdin, os.Stdout, action) {
+		return errors.New("aborted")
+	}
+	return nil
+}
+
+// askConfirmation prompts on w and reports whether the answer read from r
+// was "yes"
+func askConfirmation(r io.Reader, w io.Writer, action string) bool {
+	fmt.Fprintf(w, "This will %s. Type 'yes' to continue: ", action)
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	return strings.EqualFold(strings.TrimSpace(answer), "yes")
+}
+
+// withTx runs fn with queries bound to a transaction, committing if it
+// succeeds
+func withTx(s *State, fn func(q *database.Queries) error) error {
+	tx, err := s.Conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(s.Db.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// reset command: deletes every user, or with --feeds-only or --posts-only
+// only the feeds or posts
+func handlerReset(s *State, cmd Command) error {
+	feedsOnly, postsOnly := cmd.Bool("feeds-only"), cmd.Bool("posts-only")
+	if feedsOnly && postsOnly {
+		return errors.New("--feeds-only and --posts-only cannot be combined")
+	}
+	ctx := context.Background()
+	switch {
+	case postsOnly:
+		if err := confirm(cmd, "delete every post that nobody starred, keeping users, feeds and follows"); err != nil {
+			return err
+		}
+		// Without forgetting the cache validators, feeds would answer "not
+		// modified" and the deleted posts would never be fetched again
+		err := withTx(s, func(q *database.Queries) error {
+			if err := q.DeleteUnstarredPosts(ctx); err != nil {
+				return err
+			}
+			return q.ResetFeedFetchState(ctx)
+		})
+		if err != nil {
+			return fmt.Errorf("reset failed: %v", err)
+		}
+		fmt.Println("All unstarred posts deleted successfully.")
+	case feedsOnly:
+		if err := confirm(cmd, "delete every feed and post, keeping users"); err != nil {
+			return err
+		}
+		if err := s.Db.DeleteFeeds(ctx); err != nil {
+			return fmt.Errorf("reset failed: %v", err)
+		}
+		fmt.Println("All feeds deleted successfully.")
+	default:
+		if err := confirm(cmd, "delete every user, feed and post"); err != nil {
+			return err
+		}
+		if err := s.Db.DeleteUsers(ctx); err != nil {
+			return fmt.Errorf("reset failed: %v", err)
+		}
+		fmt.Println("All users deleted successfully.")
+	}
+	return nil
+}
+
+// user delete: removes one user. The feeds they added are given to another
+// user with --transfer-to, or deleted with --delete-feeds.
+func handlerUserDelete(s *State, cmd Command) error {
+	ctx := context.Background()
+	name, transferTo, deleteFeeds := cmd.Args[0], cmd.String("transfer-to"), cmd.Bool("delete-feeds")
+	if transferTo != "" && deleteFeeds {
+		return errors.New("--transfer-to and --delete-feeds cannot be combined")
+	}
+	user, err := s.Db.GetUser(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user '%s' does not exist", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %v", err)
+	}
+	var recipient database.User
+	if transferTo != "" {
+		if transferTo == name {
+			return errors.New("cannot transfer feeds to the user being deleted")
+		}
+		recipient, err = s.Db.GetUser(ctx, transferTo)
+		if err != nil {
+			return fmt.Errorf("user '%s' does not exist", transferTo)
+		}
+	}
+	count, err := s.Db.CountFeedsByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count feeds: %v", err)
+	}
+	if count > 0 && transferTo == "" && !deleteFeeds {
+		return fmt.Errorf("'%s' added %d feed(s); pass --transfer-to <user> to keep them or --delete-feeds to delete them", name, count)
+	}
+
+	action := fmt.Sprintf("delete user '%s'", name)
+	switch {
+	case count > 0 && transferTo != "":
+		action += fmt.Sprintf(" and give their %d feed(s) to '%s'", count, transferTo)
+	case count > 0:
+		action += fmt.Sprintf(" and their %d feed(s) with every post", count)
+	}
+	if err := confirm(cmd, action); err != nil {
+		return err
+	}
+
+	// Feeds reference their creator with ON DELETE CASCADE, so they have to
+	// change hands before the user is deleted
+	err = withTx(s, func(q *database.Queries) error {
+		if transferTo != "" {
+			_, err := q.TransferFeeds(ctx, database.TransferFeedsParams{ToUserID: recipient.ID, FromUserID: user.ID})
+			if err != nil {
+				return err
+			}
+		}
+		return q.DeleteUser(ctx, user.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+	if s.Cfg.CurrentUserName == name {
+		if err := s.Cfg.SetUser(""); err != nil {
+			return fmt.Errorf("failed to clear current user: %v", err)
+		}
+	}
+	fmt.Printf("User '%s' deleted.\n", name)
+	return nil
+}
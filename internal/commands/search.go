@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"aggreGATOR/internal/database"
+	"aggreGATOR/internal/rssfeed"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type searchRecord struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	FeedName    string     `json:"feed_name"`
+	PublishedAt *time.Time `json:"published_at"`
+	Rank        float32    `json:"rank"`
+	Snippet     string     `json:"snippet" table:"-"`
+}
+
+// search command: full-text search over post titles and descriptions,
+// restricted to the current user's followed feeds unless --all is given
+func handlerSearch(s *State, cmd Command, user database.User) error {
+	all := cmd.Bool("all")
+	feedFilter := cmd.String("feed")
+	since := cmd.String("since")
+	limit := cmd.Int("limit")
+	query := strings.TrimSpace(strings.Join(cmd.Args, " "))
+	if query == "" {
+		return fmt.Errorf("search requires a query argument")
+	}
+	if limit < 1 {
+		return fmt.Errorf("--limit must be at least 1")
+	}
+	params := database.SearchPostsParams{
+		Search:     query,
+		AllFeeds:   all,
+		UserID:     user.ID,
+		MaxResults: int32(limit),
+	}
+	if feedFilter != "" {
+		var feedID uuid.UUID
+		var err error
+		if all {
+			feed, err := s.Db.GetFeedByUrl(context.Background(), feedFilter)
+			if err != nil {
+				return fmt.Errorf("could not find feed with url %s: %v", feedFilter, err)
+			}
+			feedID = feed.ID
+		} else {
+			feedID, err = resolveFollowedFeed(s, user, feedFilter)
+			if err != nil {
+				return err
+			}
+		}
+		params.FeedID = uuid.NullUUID{UUID: feedID, Valid: true}
+	}
+	if since != "" {
+		loc, err := s.Cfg.Location()
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %v", s.Cfg.Timezone, err)
+		}
+		t, err := parseTimeBound(since, loc)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %v", err)
+		}
+		params.Since = sql.NullTime{Time: t, Valid: true}
+	}
+	results, err := s.Db.SearchPosts(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("search failed: %v", err)
+	}
+	if s.Output.structured() {
+		records := make([]searchRecord, len(results))
+		for i, r := range results {
+			records[i] = searchRecord{
+				ID:          r.ID,
+				Title:       r.Title,
+				URL:         r.Url,
+				FeedName:    r.FeedName,
+				PublishedAt: nullTimePtr(r.PublishedAt),
+				Rank:        r.Rank,
+				Snippet:     rssfeed.PlainText(r.Snippet),
+			}
+		}
+		return writeRecords(os.Stdout, s.Output, records)
+	}
+	if len(results) == 0 {
+		fmt.Println("No matching posts.")
+		return nil
+	}
+	for _, r := range results {
+		published := "unknown"
+		if r.PublishedAt.Valid {
+			published = r.PublishedAt.Time.Format(time.RFC3339)
+		}
+		fmt.Printf("ID: %s\nTitle: %s\nFeed: %s\nURL: %s\nPublished: %s\nRank: %.3f\n%s\n---\n",
+			r.ID, r.Title, r.FeedName, r.Url, published, r.Rank, rssfeed.PlainText(r.Snippet))
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"aggreGATOR/internal/database"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type starredRecord struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	FeedID      uuid.UUID  `json:"feed_id" table:"-"`
+	FeedName    string     `json:"feed_name"`
+	PublishedAt *time.Time `json:"published_at" table:"-"`
+	StarredAt   time.Time  `json:"starred_at"`
+	Description *string    `json:"description" table:"-"`
+}
+
+func handlerStar(s *State, cmd Command, user database.User) error {
+	post, err := findPost(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	err = s.Db.StarPost(context.Background(), database.StarPostParams{
+		UserID:    user.ID,
+		PostID:    post.ID,
+		StarredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to star post: %v", err)
+	}
+	fmt.Printf("Starred '%s'\n", post.Title)
+	return nil
+}
+
+func handlerUnstar(s *State, cmd Command, user database.User) error {
+	post, err := findPost(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	n, err := s.Db.UnstarPost(context.Background(), database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unstar post: %v", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("post '%s' is not starred", post.Title)
+	}
+	fmt.Printf("Unstarred '%s'\n", post.Title)
+	return nil
+}
+
+// starred command: prints the current user's reading list, most recently
+// starred first, with an optional limit
+func handlerStarred(s *State, cmd Command, user database.User) error {
+	limit, err := cmd.intArg(0, "limit", 20)
+	if err != nil {
+		return err
+	}
+	posts, err := s.Db.GetStarredPostsForUser(context.Background(), database.GetStarredPostsForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get starred posts: %v", err)
+	}
+	if s.Output.structured() {
+		records := make([]starredRecord, len(posts))
+		for i, p := range posts {
+			records[i] = starredRecord{
+				ID:          p.ID,
+				Title:       p.Title,
+				URL:         p.Url,
+				FeedID:      p.FeedID,
+				FeedName:    p.FeedName,
+				PublishedAt: nullTimePtr(p.PublishedAt),
+				StarredAt:   p.StarredAt,
+				Description: nullStringPtr(p.Description),
+			}
+		}
+		return writeRecords(os.Stdout, s.Output, records)
+	}
+	if len(posts) == 0 {
+		fmt.Println("No starred posts.")
+		return nil
+	}
+	for _, post := range posts {
+		fmt.Printf("ID: %s\nTitle: %s\nFeed: %s\nURL: %s\nStarred: %s\n---\n", post.ID, post.Title, post.FeedName, post.Url, post.StarredAt.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"aggreGATOR/internal/database"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// normalizeTag trims a tag and rejects empty ones. Nested OPML folders become
+// tags joined with "/", e.g. "tech/go".
+func normalizeTag(tag string) (string, error) {
+	parts := strings.Split(tag, "/")
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+		if parts[i] == "" {
+			return "", fmt.Errorf("invalid tag %q", tag)
+		}
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// followTags returns the current user's tags for each followed feed
+func followTags(s *State, user database.User) (map[uuid.UUID][]string, error) {
+	rows, err := s.Db.GetFeedFollowTagsForUser(context.Background(), user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %v", err)
+	}
+	tags := make(map[uuid.UUID][]string)
+	for _, r := range rows {
+		tags[r.FeedID] = append(tags[r.FeedID], r.Tag)
+	}
+	return tags, nil
+}
+
+// checkTagUsed returns an error if none of the user's followed feeds has the
+// tag, so a typo isn't mistaken for a group with no new posts
+func checkTagUsed(s *State, user database.User, tag string) error {
+	tags, err := followTags(s, user)
+	if err != nil {
+		return err
+	}
+	for _, feedTags := range tags {
+		if slices.Contains(feedTags, tag) {
+			return nil
+		}
+	}
+	return fmt.Errorf("none of the feeds you follow are tagged '%s'", tag)
+}
+
+// tagFollow adds a tag to the user's follow of a feed; tagging twice is a
+// no-op
+func tagFollow(s *State, user database.User, feedID uuid.UUID, tag string) error {
+	follow, err := s.Db.GetFeedFollow(context.Background(), database.GetFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		return fmt.Errorf("could not find feed follow: %v", err)
+	}
+	err = s.Db.TagFeedFollow(context.Background(), database.TagFeedFollowParams{
+		FeedFollowID: follow.ID,
+		Tag:          tag,
+		CreatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag feed: %v", err)
+	}
+	return nil
+}
+
+func handlerTag(s *State, cmd Command, user database.User) error {
+	tag, err := normalizeTag(cmd.Args[1])
+	if err != nil {
+		return err
+	}
+	feedID, err := resolveFollowedFeed(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if err := tagFollow(s, user, feedID, tag); err != nil {
+		return err
+	}
+	fmt.Printf("Tagged %s with '%s'\n", cmd.Args[0], tag)
+	return nil
+}
+
+func handlerUntag(s *State, cmd Command, user database.User) error {
+	tag, err := normalizeTag(cmd.Args[1])
+	if err != nil {
+		return err
+	}
+	feedID, err := resolveFollowedFeed(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	follow, err := s.Db.GetFeedFollow(context.Background(), database.GetFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		return fmt.Errorf("could not find feed follow: %v", err)
+	}
+	n, err := s.Db.UntagFeedFollow(context.Background(), database.UntagFeedFollowParams{
+		FeedFollowID: follow.ID,
+		Tag:          tag,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to untag feed: %v", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s is not tagged '%s'", cmd.Args[0], tag)
+	}
+	fmt.Printf("Removed tag '%s' from %s\n", tag, cmd.Args[0])
+	return nil
+}
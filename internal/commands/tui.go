@@ -0,0 +1,403 @@
+package commands
+
+import (
+	"aggreGATOR/internal/database"
+	"aggreGATOR/internal/rssfeed"
+	"aggreGATOR/internal/terminal"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tuiPostLimit caps how many posts of a feed the reader loads at once
+const tuiPostLimit = 200
+
+const tuiHelp = "j/k move  tab switch pane  m read/unread  s star  r refresh  y copy url  q quit"
+
+type tuiPane int
+
+const (
+	paneFeeds tuiPane = iota
+	panePosts
+)
+
+// tuiModel holds the reader's state between redraws
+type tuiModel struct {
+	s    *State
+	user database.User
+	loc  *time.Location
+
+	feeds   []database.GetFollowedFeedsWithUnreadCountsRow
+	posts   []database.GetPostsForUserRow
+	feedIdx int
+	postIdx int
+	focus   tuiPane
+	// scroll offsets of the feed and post lists
+	feedTop int
+	postTop int
+	status  string
+}
+
+// tui command: full-screen reader with followed feeds, their posts and a
+// preview of the selected post
+func handlerTUI(s *State, cmd Command, user database.User) error {
+	loc, err := s.Cfg.Location()
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %v", s.Cfg.Timezone, err)
+	}
+	m := &tuiModel{s: s, user: user, loc: loc, status: tuiHelp}
+	if err := m.loadFeeds(); err != nil {
+		return err
+	}
+	if err := m.loadPosts(); err != nil {
+		return err
+	}
+
+	term, err := terminal.Open()
+	if err != nil {
+		return err
+	}
+	defer term.Close()
+	for {
+		if err := m.draw(term); err != nil {
+			return err
+		}
+		key, err := term.ReadKey()
+		if err != nil {
+			return err
+		}
+		if key == 'q' || key == terminal.KeyCtrlC {
+			return nil
+		}
+		m.handleKey(term, key)
+	}
+}
+
+func (m *tuiModel) loadFeeds() error {
+	feeds, err := m.s.Db.GetFollowedFeedsWithUnreadCounts(context.Background(), m.user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get followed feeds: %v", err)
+	}
+	m.feeds = feeds
+	m.feedIdx = clamp(m.feedIdx, len(feeds))
+	return nil
+}
+
+// loadPosts loads the posts of the selected feed, read ones included, keeping
+// the selection on the same post when it is still there
+func (m *tuiModel) loadPosts() error {
+	feed, ok := m.selectedFeed()
+	if !ok {
+		m.posts = nil
+		return nil
+	}
+	var selected uuid.UUID
+	if post, ok := m.selectedPost(); ok {
+		selected = post.ID
+	}
+	posts, err := m.s.Db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
+		UserID:      m.user.ID,
+		IncludeRead: true,
+		FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+		PageSize:    tuiPostLimit,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get posts: %v", err)
+	}
+	m.posts = posts
+	m.postIdx = 0
+	for i, p := range posts {
+		if p.ID == selected {
+			m.postIdx = i
+		}
+	}
+	return nil
+}
+
+func (m *tuiModel) selectedFeed() (database.GetFollowedFeedsWithUnreadCountsRow, bool) {
+	if m.feedIdx >= len(m.feeds) {
+		return database.GetFollowedFeedsWithUnreadCountsRow{}, false
+	}
+	return m.feeds[m.feedIdx], true
+}
+
+func (m *tuiModel) selectedPost() (database.GetPostsForUserRow, bool) {
+	if m.postIdx >= len(m.posts) {
+		return database.GetPostsForUserRow{}, false
+	}
+	return m.posts[m.postIdx], true
+}
+
+// clamp keeps an index within a list of n items
+func clamp(i, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+func (m *tuiModel) handleKey(term *terminal.Terminal, key terminal.Key) {
+	var err error
+	switch key {
+	case 'j', terminal.KeyDown:
+		err = m.move(1)
+	case 'k', terminal.KeyUp:
+		err = m.move(-1)
+	case terminal.KeyPageDown:
+		err = m.move(10)
+	case terminal.KeyPageUp:
+		err = m.move(-10)
+	case terminal.KeyTab, 'h', 'l', terminal.KeyLeft, terminal.KeyRight, terminal.KeyEnter:
+		if m.focus == paneFeeds {
+			m.focus = panePosts
+		} else {
+			m.focus = paneFeeds
+		}
+	case 'm':
+		err = m.toggleRead()
+	case 's':
+		err = m.toggleStar()
+	case 'r':
+		err = m.refresh(term)
+	case 'y':
+		if post, ok := m.selectedPost(); ok {
+			term.Copy(post.Url)
+			m.status = "Copied " + post.Url
+		}
+	default:
+		m.status = tuiHelp
+	}
+	if err != nil {
+		m.status = "Error: " + err.Error()
+	}
+}
+
+func (m *tuiModel) move(delta int) error {
+	if m.focus == panePosts {
+		m.postIdx = clamp(m.postIdx+delta, len(m.posts))
+		return nil
+	}
+	idx := clamp(m.feedIdx+delta, len(m.feeds))
+	if idx == m.feedIdx {
+		return nil
+	}
+	m.feedIdx = idx
+	m.postIdx, m.postTop = 0, 0
+	m.posts = nil
+	return m.loadPosts()
+}
+
+func (m *tuiModel) toggleRead() error {
+	post, ok := m.selectedPost()
+	if !ok {
+		return nil
+	}
+	if post.ReadAt.Valid {
+		err := m.s.Db.MarkPostUnread(context.Background(), database.MarkPostUnreadParams{
+			UserID: m.user.ID,
+			PostID: post.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark post unread: %v", err)
+		}
+		m.status = fmt.Sprintf("Marked '%s' as unread", post.Title)
+	} else {
+		err := m.s.Db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+			UserID: m.user.ID,
+			PostID: post.ID,
+			ReadAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark post read: %v", err)
+		}
+		m.status = fmt.Sprintf("Marked '%s' as read", post.Title)
+	}
+	if err := m.loadFeeds(); err != nil {
+		return err
+	}
+	return m.loadPosts()
+}
+
+// toggleStar unstars the selected post if it is starred and stars it
+// otherwise
+func (m *tuiModel) toggleStar() error {
+	post, ok := m.selectedPost()
+	if !ok {
+		return nil
+	}
+	n, err := m.s.Db.UnstarPost(context.Background(), database.UnstarPostParams{
+		UserID: m.user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unstar post: %v", err)
+	}
+	if n > 0 {
+		m.status = fmt.Sprintf("Unstarred '%s'", post.Title)
+		return nil
+	}
+	err = m.s.Db.StarPost(context.Background(), database.StarPostParams{
+		UserID:    m.user.ID,
+		PostID:    post.ID,
+		StarredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to star post: %v", err)
+	}
+	m.status = fmt.Sprintf("Starred '%s'", post.Title)
+	return nil
+}
+
+// refresh fetches the selected feed the same way "agg --feed" does, showing
+// that it is busy while the fetch blocks input
+func (m *tuiModel) refresh(term *terminal.Terminal) error {
+	selected, ok := m.selectedFeed()
+	if !ok {
+		return nil
+	}
+	feed, err := m.s.Db.GetFeedByUrl(context.Background(), selected.Url)
+	if err != nil {
+		return fmt.Errorf("could not find feed with url %s: %v", selected.Url, err)
+	}
+	m.status = fmt.Sprintf("Refreshing %s…", feed.Name)
+	if err := m.draw(term); err != nil {
+		return err
+	}
+	// Progress messages would draw over the screen; the status line reports
+	// the outcome instead. A manual refresh records failures but never
+	// disables a feed, and a disabled feed stays disabled until a fetch works.
+	stats := &aggStats{started: time.Now()}
+	fetchFeeds(context.Background(), m.s, aggOptions{workers: 1, out: io.Discard}, stats, []database.Feed{feed}, false)
+
+	switch {
+	case stats.failed > 0:
+		m.status = fmt.Sprintf("Failed to refresh %s", feed.Name)
+	case stats.skipped > 0:
+		m.status = fmt.Sprintf("%s is being fetched by another aggregator", feed.Name)
+	default:
+		m.status = fmt.Sprintf("Refreshed %s: %d new post(s)", feed.Name, stats.posts)
+	}
+	if err := m.loadFeeds(); err != nil {
+		return err
+	}
+	return m.loadPosts()
+}
+
+// draw renders the feeds pane on the left, the post list top right, the
+// preview of the selected post below it and a status line at the bottom
+func (m *tuiModel) draw(term *terminal.Terminal) error {
+	width, height, err := term.Size()
+	if err != nil {
+		return err
+	}
+	term.Clear()
+	if width < 40 || height < 10 {
+		term.Print(0, 0, terminal.Fit("Terminal too small", width))
+		return term.Flush()
+	}
+
+	feedWidth := min(32, width/3)
+	rightX := feedWidth + 1
+	rightWidth := width - rightX
+	bodyHeight := height - 2
+	listHeight := bodyHeight / 2
+
+	term.PrintStyled(0, 0, terminal.Bold, terminal.Fit(fmt.Sprintf(" gator - %s", m.user.Name), width))
+	for y := 1; y <= bodyHeight; y++ {
+		term.Print(feedWidth, y, "│")
+	}
+
+	m.feedTop = scrollTop(m.feedTop, m.feedIdx, bodyHeight)
+	for row := 0; row < bodyHeight && m.feedTop+row < len(m.feeds); row++ {
+		i := m.feedTop + row
+		feed := m.feeds[i]
+		count := ""
+		if feed.UnreadCount > 0 {
+			count = fmt.Sprintf(" %d", feed.UnreadCount)
+		}
+		line := terminal.Fit(" "+feed.Name, feedWidth-len(count)) + count
+		m.printItem(term, 0, row+1, line, i == m.feedIdx, m.focus == paneFeeds)
+	}
+	if len(m.feeds) == 0 {
+		term.Print(0, 1, terminal.Fit(" No followed feeds", feedWidth))
+	}
+
+	m.postTop = scrollTop(m.postTop, m.postIdx, listHeight)
+	for row := 0; row < listHeight && m.postTop+row < len(m.posts); row++ {
+		i := m.postTop + row
+		post := m.posts[i]
+		marker := "●"
+		if post.ReadAt.Valid {
+			marker = " "
+		}
+		date := ""
+		if post.PublishedAt.Valid {
+			date = " " + post.PublishedAt.Time.In(m.loc).Format("2006-01-02")
+		}
+		line := terminal.Fit(" "+marker+" "+post.Title, rightWidth-len(date)) + date
+		m.printItem(term, rightX, row+1, line, i == m.postIdx, m.focus == panePosts)
+	}
+	if len(m.posts) == 0 {
+		term.Print(rightX, 1, terminal.Fit(" No posts", rightWidth))
+	}
+
+	term.Print(rightX, listHeight+1, strings.Repeat("─", rightWidth))
+	if post, ok := m.selectedPost(); ok {
+		m.drawPreview(term, post, rightX+1, listHeight+2, rightWidth-2, bodyHeight-listHeight-1)
+	}
+
+	term.PrintStyled(0, height-1, terminal.Reverse, terminal.Fit(" "+m.status, width))
+	return term.Flush()
+}
+
+// printItem draws a list row, highlighted when selected and dimmed when its
+// pane doesn't have focus
+func (m *tuiModel) printItem(term *terminal.Terminal, x, y int, line string, selected, focused bool) {
+	switch {
+	case selected && focused:
+		term.PrintStyled(x, y, terminal.Reverse, line)
+	case selected:
+		term.PrintStyled(x, y, terminal.Bold, line)
+	default:
+		term.Print(x, y, line)
+	}
+}
+
+func (m *tuiModel) drawPreview(term *terminal.Terminal, post database.GetPostsForUserRow, x, y, width, height int) {
+	published := "unknown date"
+	if post.PublishedAt.Valid {
+		published = post.PublishedAt.Time.In(m.loc).Format("Mon, 02 Jan 2006 15:04")
+	}
+	lines := terminal.Wrap(post.Title, width)
+	titleLines := len(lines)
+	lines = append(lines, post.FeedName+" · "+published, post.Url, "")
+	if post.Description.Valid {
+		lines = append(lines, terminal.Wrap(rssfeed.PlainText(post.Description.String), width)...)
+	}
+	for i := 0; i < height && i < len(lines); i++ {
+		if i < titleLines {
+			term.PrintStyled(x, y+i, terminal.Bold, terminal.Fit(lines[i], width))
+			continue
+		}
+		term.Print(x, y+i, terminal.Fit(lines[i], width))
+	}
+}
+
+// scrollTop returns the first visible row of a list so that the selected
+// row stays within a window of the given height
+func scrollTop(top, selected, height int) int {
+	if selected < top {
+		return selected
+	}
+	if selected >= top+height {
+		return selected - height + 1
+	}
+	return top
+}
@@ -2,8 +2,11 @@ package config
 
 import (
 	"encoding/json"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 const configFileName = ".gatorconfig.json"
@@ -11,9 +14,11 @@ const configFileName = ".gatorconfig.json"
 // Config represents the structure of the config file
 // db_url: connection string for PostgreSQL
 // current_user_name: currently logged in user
+// timezone: IANA zone used for feed timestamps that carry no offset
 type Config struct {
 	DBUrl           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name,omitempty"`
+	Timezone        string `json:"timezone,omitempty"`
 }
 
 // getConfigFilePath returns the path to the config file in the user's home directory
@@ -65,3 +70,29 @@ func (cfg *Config) SetUser(name string) error {
 	cfg.CurrentUserName = name
 	return write(*cfg)
 }
+
+// Location returns the configured fallback timezone, defaulting to UTC
+func (cfg *Config) Location() (*time.Location, error) {
+	if cfg.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(cfg.Timezone)
+}
+
+// DSN returns db_url with the session time zone pinned to UTC. Timestamps
+// written with NOW() and those written by the aggregator then agree, and
+// both compare correctly against NOW(), whatever the server's zone.
+func (cfg *Config) DSN() (string, error) {
+	if !strings.HasPrefix(cfg.DBUrl, "postgres://") && !strings.HasPrefix(cfg.DBUrl, "postgresql://") {
+		// key=value form; a repeated key overrides the earlier one
+		return strings.TrimSpace(cfg.DBUrl + " timezone=UTC"), nil
+	}
+	u, err := url.Parse(cfg.DBUrl)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("timezone", "UTC")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -75,3 +75,26 @@ func TestSetUser(t *testing.T) {
 		t.Errorf("SetUser() did not update user: got %s, want %s", readCfg.CurrentUserName, "newuser")
 	}
 }
+
+func TestDSNPinsTimezone(t *testing.T) {
+	tests := []struct {
+		dbURL string
+		want  string
+	}{
+		{"postgres://u:p@localhost:5432/gator", "postgres://u:p@localhost:5432/gator?timezone=UTC"},
+		{"postgres://localhost/gator?sslmode=disable", "postgres://localhost/gator?sslmode=disable&timezone=UTC"},
+		{"postgresql://localhost/gator?timezone=Europe%2FBerlin", "postgresql://localhost/gator?timezone=UTC"},
+		{"host=localhost dbname=gator", "host=localhost dbname=gator timezone=UTC"},
+	}
+	for _, tt := range tests {
+		cfg := Config{DBUrl: tt.dbURL}
+		got, err := cfg.DSN()
+		if err != nil {
+			t.Errorf("DSN() for %q error: %v", tt.dbURL, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DSN() for %q = %q, want %q", tt.dbURL, got, tt.want)
+		}
+	}
+}
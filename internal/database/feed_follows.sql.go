@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,7 +17,7 @@ const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
     INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
     VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, created_at, updated_at, user_id, feed_id
+    RETURNING id, created_at, updated_at, user_id, feed_id, title
 )
 SELECT
     iff.id,
@@ -84,6 +85,29 @@ func (q *Queries) DeleteFeedFollowByUserAndUrl(ctx context.Context, arg DeleteFe
 	return err
 }
 
+const getFeedFollow = `-- name: GetFeedFollow :one
+SELECT id, created_at, updated_at, user_id, feed_id, title FROM feed_follows WHERE user_id = $1 AND feed_id = $2
+`
+
+type GetFeedFollowParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Title,
+	)
+	return i, err
+}
+
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
 SELECT
     ff.id,
@@ -91,7 +115,9 @@ SELECT
     ff.updated_at,
     ff.user_id,
     ff.feed_id,
-    f.name AS feed_name,
+    ff.title,
+    feed_display_name(ff.title, f.name, f.url, f.channel_title) AS feed_name,
+    f.url AS feed_url,
     u.name AS user_name
 FROM feed_follows ff
 INNER JOIN feeds f ON ff.feed_id = f.id
@@ -106,7 +132,9 @@ type GetFeedFollowsForUserRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Title     sql.NullString
 	FeedName  string
+	FeedUrl   string
 	UserName  string
 }
 
@@ -125,7 +153,9 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.Title,
 			&i.FeedName,
+			&i.FeedUrl,
 			&i.UserName,
 		); err != nil {
 			return nil, err
@@ -140,3 +170,72 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const getFollowedFeedsWithUnreadCounts = `-- name: GetFollowedFeedsWithUnreadCounts :many
+SELECT
+    f.id,
+    feed_display_name(ff.title, f.name, f.url, f.channel_title) AS name,
+    f.url,
+    COUNT(p.id) FILTER (WHERE pr.post_id IS NULL) AS unread_count
+FROM feed_follows ff
+INNER JOIN feeds f ON ff.feed_id = f.id
+LEFT JOIN posts p ON p.feed_id = f.id
+LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = ff.user_id
+WHERE ff.user_id = $1
+GROUP BY f.id, ff.id
+ORDER BY name
+`
+
+type GetFollowedFeedsWithUnreadCountsRow struct {
+	ID          uuid.UUID
+	Name        string
+	Url         string
+	UnreadCount int64
+}
+
+func (q *Queries) GetFollowedFeedsWithUnreadCounts(ctx context.Context, userID uuid.UUID) ([]GetFollowedFeedsWithUnreadCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedsWithUnreadCounts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowedFeedsWithUnreadCountsRow
+	for rows.Next() {
+		var i GetFollowedFeedsWithUnreadCountsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.UnreadCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateFeedFollowTitle = `-- name: UpdateFeedFollowTitle :execrows
+UPDATE feed_follows SET title = $3, updated_at = NOW()
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type UpdateFeedFollowTitleParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+	Title  sql.NullString
+}
+
+func (q *Queries) UpdateFeedFollowTitle(ctx context.Context, arg UpdateFeedFollowTitleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateFeedFollowTitle, arg.UserID, arg.FeedID, arg.Title)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -7,14 +7,127 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const claimFeed = `-- name: ClaimFeed :one
+UPDATE feeds
+SET lease_expires_at = NOW() + make_interval(secs => $1::int)
+WHERE id = $2 AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_expires_at, failure_count, last_error, last_success_at, next_fetch_at, disabled_at, channel_title
+`
+
+type ClaimFeedParams struct {
+	LeaseSeconds int32
+	ID           uuid.UUID
+}
+
+func (q *Queries) ClaimFeed(ctx context.Context, arg ClaimFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, claimFeed, arg.LeaseSeconds, arg.ID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseExpiresAt,
+		&i.FailureCount,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.ChannelTitle,
+	)
+	return i, err
+}
+
+const claimFeedsToFetch = `-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET lease_expires_at = NOW() + make_interval(secs => $1::int)
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE (lease_expires_at IS NULL OR lease_expires_at < NOW())
+        AND disabled_at IS NULL
+        AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    ORDER BY last_fetched_at ASC NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_expires_at, failure_count, last_error, last_success_at, next_fetch_at, disabled_at, channel_title
+`
+
+type ClaimFeedsToFetchParams struct {
+	LeaseSeconds int32
+	BatchSize    int32
+}
+
+// Leases the least recently fetched due feeds that no other aggregator holds.
+// SKIP LOCKED lets concurrent claims pass over each other's rows, and the
+// lease keeps a claimed feed out of rotation until MarkFeedFetched releases
+// it or it expires after a crash.
+func (q *Queries) ClaimFeedsToFetch(ctx context.Context, arg ClaimFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimFeedsToFetch, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LeaseExpiresAt,
+			&i.FailureCount,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.NextFetchAt,
+			&i.DisabledAt,
+			&i.ChannelTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countFeedsByUser = `-- name: CountFeedsByUser :one
+SELECT COUNT(*) FROM feeds WHERE user_id = $1
+`
+
+func (q *Queries) CountFeedsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFeedsByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES (gen_random_uuid(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_expires_at, failure_count, last_error, last_success_at, next_fetch_at, disabled_at, channel_title
 `
 
 type CreateFeedParams struct {
@@ -34,12 +147,131 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseExpiresAt,
+		&i.FailureCount,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.ChannelTitle,
 	)
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const deleteFeeds = `-- name: DeleteFeeds :exec
+DELETE FROM feeds
+`
+
+func (q *Queries) DeleteFeeds(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteFeeds)
+	return err
+}
+
+const getAllFeeds = `-- name: GetAllFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_expires_at, failure_count, last_error, last_success_at, next_fetch_at, disabled_at, channel_title FROM feeds
+ORDER BY last_fetched_at ASC NULLS FIRST
+`
+
+// Every feed, including ones backing off or disabled, in fetch order.
+func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getAllFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LeaseExpiresAt,
+			&i.FailureCount,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.NextFetchAt,
+			&i.DisabledAt,
+			&i.ChannelTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDueFeeds = `-- name: GetDueFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_expires_at, failure_count, last_error, last_success_at, next_fetch_at, disabled_at, channel_title FROM feeds
+WHERE disabled_at IS NULL AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+ORDER BY last_fetched_at ASC NULLS FIRST
+`
+
+func (q *Queries) GetDueFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getDueFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LeaseExpiresAt,
+			&i.FailureCount,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.NextFetchAt,
+			&i.DisabledAt,
+			&i.ChannelTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds WHERE url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_expires_at, failure_count, last_error, last_success_at, next_fetch_at, disabled_at, channel_title FROM feeds WHERE url = $1
 `
 
 func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
@@ -53,19 +285,141 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseExpiresAt,
+		&i.FailureCount,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.ChannelTitle,
+	)
+	return i, err
+}
+
+const getFeedDetails = `-- name: GetFeedDetails :one
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.etag, feeds.last_modified, feeds.lease_expires_at, feeds.failure_count, feeds.last_error, feeds.last_success_at, feeds.next_fetch_at, feeds.disabled_at, feeds.channel_title, users.name AS user_name,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id) AS follower_count,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = feeds.id) AS post_count
+FROM feeds
+INNER JOIN users ON feeds.user_id = users.id
+WHERE feeds.url = $1
+`
+
+type GetFeedDetailsRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	Url            string
+	UserID         uuid.UUID
+	LastFetchedAt  sql.NullTime
+	Etag           sql.NullString
+	LastModified   sql.NullString
+	LeaseExpiresAt sql.NullTime
+	FailureCount   int32
+	LastError      sql.NullString
+	LastSuccessAt  sql.NullTime
+	NextFetchAt    sql.NullTime
+	DisabledAt     sql.NullTime
+	ChannelTitle   sql.NullString
+	UserName       string
+	FollowerCount  int64
+	PostCount      int64
+}
+
+func (q *Queries) GetFeedDetails(ctx context.Context, url string) (GetFeedDetailsRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedDetails, url)
+	var i GetFeedDetailsRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseExpiresAt,
+		&i.FailureCount,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.NextFetchAt,
+		&i.DisabledAt,
+		&i.ChannelTitle,
+		&i.UserName,
+		&i.FollowerCount,
+		&i.PostCount,
 	)
 	return i, err
 }
 
+const getFeedsByStatus = `-- name: GetFeedsByStatus :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_expires_at, failure_count, last_error, last_success_at, next_fetch_at, disabled_at, channel_title FROM feeds
+ORDER BY disabled_at IS NULL, failure_count DESC, name
+`
+
+func (q *Queries) GetFeedsByStatus(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LeaseExpiresAt,
+			&i.FailureCount,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.NextFetchAt,
+			&i.DisabledAt,
+			&i.ChannelTitle,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFeedsWithUser = `-- name: GetFeedsWithUser :many
-SELECT feeds.name, feeds.url, users.name AS user_name FROM feeds
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, users.name AS user_name,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id) AS follower_count,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = feeds.id) AS post_count
+FROM feeds
 INNER JOIN users ON feeds.user_id = users.id
 `
 
 type GetFeedsWithUserRow struct {
-	Name     string
-	Url      string
-	UserName string
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Name          string
+	Url           string
+	UserID        uuid.UUID
+	LastFetchedAt sql.NullTime
+	UserName      string
+	FollowerCount int64
+	PostCount     int64
 }
 
 func (q *Queries) GetFeedsWithUser(ctx context.Context) ([]GetFeedsWithUserRow, error) {
@@ -77,7 +431,18 @@ func (q *Queries) GetFeedsWithUser(ctx context.Context) ([]GetFeedsWithUserRow,
 	var items []GetFeedsWithUserRow
 	for rows.Next() {
 		var i GetFeedsWithUserRow
-		if err := rows.Scan(&i.Name, &i.Url, &i.UserName); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.UserName,
+			&i.FollowerCount,
+			&i.PostCount,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -91,34 +456,159 @@ func (q *Queries) GetFeedsWithUser(ctx context.Context) ([]GetFeedsWithUserRow,
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1
+const markFeedFetched = `-- name: MarkFeedFetched :exec
+UPDATE feeds
+SET last_fetched_at = NOW(), updated_at = NOW(), lease_expires_at = NULL
+WHERE id = $1
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Feed
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Name,
-		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
+func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+	return err
+}
+
+const recordFeedFailure = `-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET failure_count = $1, last_error = $2,
+    next_fetch_at = NOW() + make_interval(secs => $3::int),
+    disabled_at = CASE WHEN $4::bool THEN COALESCE(disabled_at, NOW()) ELSE disabled_at END,
+    updated_at = NOW()
+WHERE id = $5
+`
+
+type RecordFeedFailureParams struct {
+	FailureCount   int32
+	LastError      sql.NullString
+	BackoffSeconds int32
+	Disable        bool
+	ID             uuid.UUID
+}
+
+// The retry time is computed from the database clock, like leases, so it
+// compares correctly against NOW() whatever the aggregator's time zone. A
+// disabled feed stays disabled, since the time it was first disabled, until
+// it is fetched successfully.
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFailure,
+		arg.FailureCount,
+		arg.LastError,
+		arg.BackoffSeconds,
+		arg.Disable,
+		arg.ID,
 	)
-	return i, err
+	return err
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :exec
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
 UPDATE feeds
-SET last_fetched_at = NOW(), updated_at = NOW()
+SET failure_count = 0, last_error = NULL, last_success_at = NOW(), next_fetch_at = NULL, disabled_at = NULL, updated_at = NOW()
 WHERE id = $1
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+func (q *Queries) RecordFeedSuccess(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess, id)
+	return err
+}
+
+const releaseFeedLease = `-- name: ReleaseFeedLease :exec
+UPDATE feeds SET lease_expires_at = NULL WHERE id = $1
+`
+
+func (q *Queries) ReleaseFeedLease(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, releaseFeedLease, id)
+	return err
+}
+
+const resetFeedFetchState = `-- name: ResetFeedFetchState :exec
+UPDATE feeds
+SET last_fetched_at = NULL, etag = NULL, last_modified = NULL, updated_at = NOW()
+`
+
+// Forgets when and how every feed was last fetched, so the next run fetches
+// each one in full instead of getting "not modified" responses.
+func (q *Queries) ResetFeedFetchState(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetFeedFetchState)
+	return err
+}
+
+const transferFeeds = `-- name: TransferFeeds :execrows
+UPDATE feeds SET user_id = $1, updated_at = NOW()
+WHERE user_id = $2
+`
+
+type TransferFeedsParams struct {
+	ToUserID   uuid.UUID
+	FromUserID uuid.UUID
+}
+
+func (q *Queries) TransferFeeds(ctx context.Context, arg TransferFeedsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, transferFeeds, arg.ToUserID, arg.FromUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateFeedChannelTitle = `-- name: UpdateFeedChannelTitle :exec
+UPDATE feeds SET channel_title = $2 WHERE id = $1
+`
+
+type UpdateFeedChannelTitleParams struct {
+	ID           uuid.UUID
+	ChannelTitle sql.NullString
+}
+
+func (q *Queries) UpdateFeedChannelTitle(ctx context.Context, arg UpdateFeedChannelTitleParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedChannelTitle, arg.ID, arg.ChannelTitle)
+	return err
+}
+
+const updateFeedCacheValidators = `-- name: UpdateFeedCacheValidators :exec
+UPDATE feeds
+SET etag = $2, last_modified = $3, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedCacheValidatorsParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCacheValidators(ctx context.Context, arg UpdateFeedCacheValidatorsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheValidators, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const updateFeedName = `-- name: UpdateFeedName :exec
+UPDATE feeds SET name = $2, updated_at = NOW() WHERE id = $1
+`
+
+type UpdateFeedNameParams struct {
+	ID   uuid.UUID
+	Name string
+}
+
+func (q *Queries) UpdateFeedName(ctx context.Context, arg UpdateFeedNameParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedName, arg.ID, arg.Name)
+	return err
+}
+
+const updateFeedUrl = `-- name: UpdateFeedUrl :exec
+UPDATE feeds
+SET url = $2, etag = NULL, last_modified = NULL, failure_count = 0, last_error = NULL,
+    next_fetch_at = NULL, disabled_at = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedUrlParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+// Moving a feed resets its cache validators and failure state, so the new
+// URL is fetched in full on the next run.
+func (q *Queries) UpdateFeedUrl(ctx context.Context, arg UpdateFeedUrlParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedUrl, arg.ID, arg.Url)
 	return err
 }
@@ -0,0 +1,88 @@
+package database_test
+
+import (
+	"aggreGATOR/internal/database"
+	"aggreGATOR/internal/migrate"
+	"aggreGATOR/sql/schema"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// testQueries connects to the database in GATOR_TEST_DB_URL and migrates it,
+// skipping the test when the variable isn't set. Every test works inside a
+// transaction that is rolled back afterwards.
+func testQueries(t *testing.T) *database.Queries {
+	t.Helper()
+	url := os.Getenv("GATOR_TEST_DB_URL")
+	if url == "" {
+		t.Skip("GATOR_TEST_DB_URL not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrations, err := migrate.Load(schema.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrate.New(db, migrations).Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return database.New(db).WithTx(tx)
+}
+
+func TestRecordFeedFailureKeepsFeedDisabled(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	user, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "test-" + uuid.NewString()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := q.CreateFeed(ctx, database.CreateFeedParams{Name: "test", Url: "https://example.com/" + uuid.NewString(), UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := database.RecordFeedFailureParams{
+		ID:             feed.ID,
+		FailureCount:   10,
+		LastError:      sql.NullString{String: "boom", Valid: true},
+		BackoffSeconds: 60,
+		Disable:        true,
+	}
+	if err := q.RecordFeedFailure(ctx, failure); err != nil {
+		t.Fatal(err)
+	}
+	disabled, err := q.GetFeedByUrl(ctx, feed.Url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !disabled.DisabledAt.Valid {
+		t.Fatal("feed was not disabled")
+	}
+
+	// e.g. a refresh from the tui, or agg --max-failures 0
+	failure.FailureCount, failure.Disable = 11, false
+	if err := q.RecordFeedFailure(ctx, failure); err != nil {
+		t.Fatal(err)
+	}
+	got, err := q.GetFeedByUrl(ctx, feed.Url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.DisabledAt.Valid || !got.DisabledAt.Time.Equal(disabled.DisabledAt.Time) {
+		t.Errorf("disabled_at after another failure = %v, want %v", got.DisabledAt, disabled.DisabledAt)
+	}
+}
@@ -12,13 +12,22 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	Url            string
+	UserID         uuid.UUID
+	LastFetchedAt  sql.NullTime
+	Etag           sql.NullString
+	LastModified   sql.NullString
+	LeaseExpiresAt sql.NullTime
+	FailureCount   int32
+	LastError      sql.NullString
+	LastSuccessAt  sql.NullTime
+	NextFetchAt    sql.NullTime
+	DisabledAt     sql.NullTime
+	ChannelTitle   sql.NullString
 }
 
 type FeedFollow struct {
@@ -27,6 +36,13 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Title     sql.NullString
+}
+
+type FeedFollowTag struct {
+	FeedFollowID uuid.UUID
+	Tag          string
+	CreatedAt    time.Time
 }
 
 type Post struct {
@@ -36,10 +52,22 @@ type Post struct {
 	Title       string
 	Url         string
 	Description sql.NullString
-	PublishedAt time.Time
+	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
 }
 
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostStar struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	StarredAt time.Time
+}
+
 type User struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
@@ -35,7 +35,7 @@ type CreatePostParams struct {
 	Title       string
 	Url         string
 	Description sql.NullString
-	PublishedAt time.Time
+	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
 }
 
@@ -64,30 +64,116 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 	return i, err
 }
 
+const deleteUnstarredPosts = `-- name: DeleteUnstarredPosts :exec
+DELETE FROM posts WHERE id NOT IN (SELECT post_id FROM post_stars)
+`
+
+// Starred posts are kept: a reading list is exempt from any cleanup.
+func (q *Queries) DeleteUnstarredPosts(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteUnstarredPosts)
+	return err
+}
+
+const getPostByID = `-- name: GetPostByID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts WHERE id = $1
+`
+
+func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+	)
+	return i, err
+}
+
+const getPostByUrl = `-- name: GetPostByUrl :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts WHERE url = $1
+`
+
+func (q *Queries) GetPostByUrl(ctx context.Context, url string) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByUrl, url)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+	)
+	return i, err
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, feed_display_name(ff.title, f.name, f.url, f.channel_title) AS feed_name, pr.read_at
 FROM posts p
-JOIN feeds f 
-    ON p.feed_id = f.id
-WHERE f.user_id = $1
-ORDER BY p.published_at DESC
-LIMIT $2
+JOIN feeds f ON p.feed_id = f.id
+JOIN feed_follows ff ON p.feed_id = ff.feed_id
+LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = ff.user_id
+WHERE ff.user_id = $1
+    AND ($2::bool OR pr.read_at IS NULL)
+    AND ($3::uuid IS NULL OR p.feed_id = $3)
+    AND ($4::timestamp IS NULL OR COALESCE(p.published_at, p.created_at) >= $4)
+    AND ($5::timestamp IS NULL OR COALESCE(p.published_at, p.created_at) < $5)
+    AND ($6::text IS NULL OR EXISTS (
+        SELECT 1 FROM feed_follow_tags fft WHERE fft.feed_follow_id = ff.id AND fft.tag = $6
+    ))
+ORDER BY COALESCE(p.published_at, p.created_at) DESC
+LIMIT $7 OFFSET $8
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID      uuid.UUID
+	IncludeRead bool
+	FeedID      uuid.NullUUID
+	Since       sql.NullTime
+	Until       sql.NullTime
+	Tag         sql.NullString
+	PageSize    int32
+	PageOffset  int32
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+type GetPostsForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	FeedName    string
+	ReadAt      sql.NullTime
+}
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.IncludeRead,
+		arg.FeedID,
+		arg.Since,
+		arg.Until,
+		arg.Tag,
+		arg.PageSize,
+		arg.PageOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []GetPostsForUserRow
 	for rows.Next() {
-		var i Post
+		var i GetPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -97,6 +183,87 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.FeedName,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT
+    p.id,
+    p.title,
+    p.url,
+    p.published_at,
+    feed_display_name(ff.title, f.name, f.url, f.channel_title) AS feed_name,
+    ts_rank(to_tsvector('english', p.title || ' ' || COALESCE(p.description, '')), tsq)::real AS rank,
+    ts_headline('english', p.title || ' ' || COALESCE(p.description, ''), tsq, 'StartSel=**, StopSel=**, MaxWords=30, MinWords=10')::text AS snippet
+FROM posts p
+JOIN feeds f ON p.feed_id = f.id
+CROSS JOIN websearch_to_tsquery('english', $1) tsq
+LEFT JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = $2
+WHERE to_tsvector('english', p.title || ' ' || COALESCE(p.description, '')) @@ tsq
+    AND ($3::bool OR ff.id IS NOT NULL)
+    AND ($4::uuid IS NULL OR p.feed_id = $4)
+    AND ($5::timestamp IS NULL OR COALESCE(p.published_at, p.created_at) >= $5)
+ORDER BY rank DESC, COALESCE(p.published_at, p.created_at) DESC
+LIMIT $6
+`
+
+type SearchPostsParams struct {
+	Search     string
+	UserID     uuid.UUID
+	AllFeeds   bool
+	FeedID     uuid.NullUUID
+	Since      sql.NullTime
+	MaxResults int32
+}
+
+type SearchPostsRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt sql.NullTime
+	FeedName    string
+	Rank        float32
+	Snippet     string
+}
+
+// The tsvector expression must match posts_search_idx for the index to be used
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.Search,
+		arg.UserID,
+		arg.AllFeeds,
+		arg.FeedID,
+		arg.Since,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.FeedName,
+			&i.Rank,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,321 @@
+// Package migrate applies goose-format SQL migrations and records which
+// versions have been applied in a schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSchemaTooNew is returned when the database has migrations applied that
+// this binary doesn't know about, i.e. it was migrated by a newer version
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of gator understands; upgrade gator")
+
+// lockKey identifies the advisory lock held while migrating, so concurrent
+// gator processes don't apply the same migration twice
+const lockKey = 7_233_146_810
+
+// Migration is one schema change, read from a file named NN_name.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// String returns the migration's file name without the extension
+func (m Migration) String() string {
+	return fmt.Sprintf("%02d_%s", m.Version, m.Name)
+}
+
+// Status is a migration and when it was applied, if it has been
+type Status struct {
+	Migration
+	AppliedAt sql.NullTime
+}
+
+// Load reads every *.sql migration in fsys, ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	seen := make(map[int64]string)
+	for _, file := range files {
+		m, err := parseName(file)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[m.Version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, file)
+		}
+		seen[m.Version] = file
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		m.Up, m.Down, err = parseSections(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func parseName(file string) (Migration, error) {
+	base := strings.TrimSuffix(path.Base(file), ".sql")
+	prefix, name, ok := strings.Cut(base, "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if !ok || err != nil || version < 1 {
+		return Migration{}, fmt.Errorf("migration file %s must be named NN_description.sql", file)
+	}
+	return Migration{Version: version, Name: name}, nil
+}
+
+// parseSections splits a goose migration into its Up and Down SQL. Goose's
+// StatementBegin/End markers are dropped: each section is sent to the
+// database as a single multi-statement query.
+func parseSections(body string) (string, string, error) {
+	var up, down strings.Builder
+	var current *strings.Builder
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "-- +goose Up"):
+			current = &up
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose Down"):
+			current = &down
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose"):
+			continue
+		}
+		if current == nil {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return "", "", errors.New("SQL before the -- +goose Up annotation")
+			}
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	if strings.TrimSpace(up.String()) == "" {
+		return "", "", errors.New("missing -- +goose Up section")
+	}
+	return strings.TrimSpace(up.String()), strings.TrimSpace(down.String()), nil
+}
+
+// Migrator applies migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New returns a Migrator for the given migrations, which must be ordered by
+// version as Load returns them
+func New(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// queryer is the subset of *sql.DB, *sql.Conn and *sql.Tx the migrator uses
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ensureTable creates schema_migrations if it doesn't exist. A database
+// previously migrated with the goose CLI has its applied versions copied
+// over from goose_db_version.
+func ensureTable(ctx context.Context, q queryer) error {
+	var exists, gooseExists bool
+	err := q.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL, to_regclass('goose_db_version') IS NOT NULL`).Scan(&exists, &gooseExists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	_, err = q.ExecContext(ctx, `CREATE TABLE schema_migrations (
+    version BIGINT PRIMARY KEY,
+    applied_at TIMESTAMP NOT NULL
+)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	if !gooseExists {
+		return nil
+	}
+	// goose appends a row per up or down; the latest row of each version
+	// says whether it is currently applied
+	_, err = q.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at)
+SELECT version_id, tstamp
+FROM (
+    SELECT DISTINCT ON (version_id) version_id, is_applied, tstamp
+    FROM goose_db_version
+    WHERE version_id > 0
+    ORDER BY version_id, id DESC
+) latest
+WHERE is_applied`)
+	if err != nil {
+		return fmt.Errorf("failed to import goose_db_version: %v", err)
+	}
+	return nil
+}
+
+func applied(ctx context.Context, q queryer) (map[int64]time.Time, error) {
+	rows, err := q.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versions := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		versions[version] = at
+	}
+	return versions, rows.Err()
+}
+
+// checkKnown returns ErrSchemaTooNew if any applied version is newer than
+// the latest migration known to the binary
+func (m *Migrator) checkKnown(versions map[int64]time.Time) error {
+	var latest int64
+	if len(m.migrations) > 0 {
+		latest = m.migrations[len(m.migrations)-1].Version
+	}
+	for version := range versions {
+		if version > latest {
+			return ErrSchemaTooNew
+		}
+	}
+	return nil
+}
+
+// withLock runs f on a single connection holding the migration lock
+func (m *Migrator) withLock(ctx context.Context, f func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to lock schema: %v", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockKey)
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return f(conn)
+}
+
+// Status reports every known migration and whether it has been applied. It
+// returns ErrSchemaTooNew, along with the statuses, if the database is ahead
+// of the binary.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			at, ok := versions[migration.Version]
+			statuses = append(statuses, Status{Migration: migration, AppliedAt: sql.NullTime{Time: at, Valid: ok}})
+		}
+		return m.checkKnown(versions)
+	})
+	return statuses, err
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the ones it applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.checkKnown(versions); err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			err := run(ctx, conn, migration.Up, `INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)`, migration.Version, time.Now().UTC())
+			if err != nil {
+				return fmt.Errorf("migration %s failed: %v", migration, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the most recently applied migration and returns it. It
+// returns false if no migration is applied.
+func (m *Migrator) Down(ctx context.Context) (Migration, bool, error) {
+	var undone Migration
+	var ok bool
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.checkKnown(versions); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, applied := versions[migration.Version]; !applied {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %s has no down section", migration)
+			}
+			err := run(ctx, conn, migration.Down, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("rolling back %s failed: %v", migration, err)
+			}
+			undone, ok = migration, true
+			return nil
+		}
+		return nil
+	})
+	return undone, ok, err
+}
+
+// run executes a migration section and the statement recording it in one
+// transaction
+func run(ctx context.Context, conn *sql.Conn, migration, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,72 @@
+package migrate
+
+import (
+	"aggreGATOR/sql/schema"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"02_feeds.sql": {Data: []byte("-- +goose Up\nCREATE TABLE feeds (id UUID);\n\n-- +goose Down\nDROP TABLE feeds;")},
+		"01_users.sql": {Data: []byte("-- leading comment\n-- +goose Up\n-- +goose StatementBegin\nCREATE TABLE users (id UUID);\n-- +goose StatementEnd\n")},
+		"README.md":    {Data: []byte("not a migration")},
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("got %d migrations, want 2", len(migrations))
+	}
+	users, feeds := migrations[0], migrations[1]
+	if users.Version != 1 || users.Name != "users" || users.String() != "01_users" {
+		t.Errorf("first migration: %+v", users)
+	}
+	if users.Up != "CREATE TABLE users (id UUID);" || users.Down != "" {
+		t.Errorf("users sections: up %q, down %q", users.Up, users.Down)
+	}
+	if feeds.Up != "CREATE TABLE feeds (id UUID);" || feeds.Down != "DROP TABLE feeds;" {
+		t.Errorf("feeds sections: up %q, down %q", feeds.Up, feeds.Down)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name":          {"users.sql": {Data: []byte("-- +goose Up\nSELECT 1;")}},
+		"duplicate version": {"01_a.sql": {Data: []byte("-- +goose Up\nSELECT 1;")}, "1_b.sql": {Data: []byte("-- +goose Up\nSELECT 1;")}},
+		"missing up":        {"01_a.sql": {Data: []byte("-- +goose Down\nSELECT 1;")}},
+		"sql before up":     {"01_a.sql": {Data: []byte("SELECT 1;\n-- +goose Up\nSELECT 1;")}},
+	}
+	for name, fsys := range tests {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadEmbeddedSchema(t *testing.T) {
+	migrations, err := Load(schema.FS)
+	if err != nil {
+		t.Fatalf("Load(schema.FS) error: %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d has version %d; versions should be contiguous", i, m.Version)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %s has no down section", m)
+		}
+	}
+}
+
+func TestCheckKnown(t *testing.T) {
+	m := New(nil, []Migration{{Version: 1}, {Version: 2}})
+	if err := m.checkKnown(map[int64]time.Time{1: {}, 2: {}}); err != nil {
+		t.Errorf("checkKnown() = %v, want nil", err)
+	}
+	if err := m.checkKnown(map[int64]time.Time{3: {}}); err != ErrSchemaTooNew {
+		t.Errorf("checkKnown() = %v, want ErrSchemaTooNew", err)
+	}
+}
@@ -0,0 +1,138 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+// Document is an OPML 1.0 or 2.0 subscription list
+type Document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is either a feed (when XMLURL is set) or a folder of outlines
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Feed is a subscription found in a document, along with the names of the
+// folders it is nested in, outermost first
+type Feed struct {
+	Title   string
+	URL     string
+	Folders []string
+}
+
+// Parse reads an OPML document
+func Parse(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Feeds flattens the outline tree into the list of feeds it contains, in
+// document order
+func (d *Document) Feeds() []Feed {
+	var feeds []Feed
+	var walk func(outlines []Outline, folders []string)
+	walk = func(outlines []Outline, folders []string) {
+		for _, o := range outlines {
+			if url := strings.TrimSpace(o.XMLURL); url != "" {
+				feeds = append(feeds, Feed{
+					Title:   o.name(),
+					URL:     url,
+					Folders: append([]string(nil), folders...),
+				})
+			}
+			if len(o.Outlines) > 0 {
+				sub := folders
+				if name := o.name(); name != "" && o.XMLURL == "" {
+					sub = append(append([]string(nil), folders...), name)
+				}
+				walk(o.Outlines, sub)
+			}
+		}
+	}
+	walk(d.Body.Outlines, nil)
+	return feeds
+}
+
+// name returns the outline's title, falling back to its required text
+func (o Outline) name() string {
+	if title := strings.TrimSpace(o.Title); title != "" {
+		return title
+	}
+	return strings.TrimSpace(o.Text)
+}
+
+// New builds an OPML 2.0 document from a list of feeds, nesting each feed in
+// outlines for its folders
+func New(title string, feeds []Feed) *Document {
+	doc := &Document{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, f := range feeds {
+		outlines := &doc.Body.Outlines
+		for _, folder := range f.Folders {
+			outlines = &folderOutline(outlines, folder).Outlines
+		}
+		*outlines = append(*outlines, Outline{
+			Text:   f.Title,
+			Title:  f.Title,
+			Type:   "rss",
+			XMLURL: f.URL,
+		})
+	}
+	return doc
+}
+
+// folderOutline returns the folder with the given name among outlines,
+// appending it if it doesn't exist yet
+func folderOutline(outlines *[]Outline, name string) *Outline {
+	for i := range *outlines {
+		if o := &(*outlines)[i]; o.XMLURL == "" && o.Text == name {
+			return o
+		}
+	}
+	*outlines = append(*outlines, Outline{Text: name, Title: name})
+	return &(*outlines)[len(*outlines)-1]
+}
+
+// Write encodes the document as indented XML with an XML declaration
+func (d *Document) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(d); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
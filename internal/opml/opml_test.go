@@ -0,0 +1,100 @@
+package opml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const nestedDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>Subscriptions</title></head>
+  <body>
+    <outline text="Top Level" type="rss" xmlUrl="https://example.com/top.xml"/>
+    <outline text="Go">
+      <outline text="Go Blog" title="The Go Blog" type="rss" xmlUrl="https://go.dev/blog/feed.atom" htmlUrl="https://go.dev/blog"/>
+      <outline text="Tools">
+        <outline text="gopls" type="rss" xmlUrl=" https://example.com/gopls.xml "/>
+      </outline>
+    </outline>
+    <outline text="Empty folder"/>
+  </body>
+</opml>`
+
+// OPML 1.0 files often omit the type attribute and the head
+const v1Doc = `<opml version="1.0"><body><outline text="Old" xmlUrl="https://example.com/old.rss"/></body></opml>`
+
+func TestFeedsNested(t *testing.T) {
+	doc, err := Parse(strings.NewReader(nestedDoc))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	want := []Feed{
+		{Title: "Top Level", URL: "https://example.com/top.xml"},
+		{Title: "The Go Blog", URL: "https://go.dev/blog/feed.atom", Folders: []string{"Go"}},
+		{Title: "gopls", URL: "https://example.com/gopls.xml", Folders: []string{"Go", "Tools"}},
+	}
+	got := doc.Feeds()
+	if len(got) != len(want) {
+		t.Fatalf("got %d feeds, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Title != want[i].Title || got[i].URL != want[i].URL || !reflect.DeepEqual(got[i].Folders, want[i].Folders) {
+			t.Errorf("feed %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFeedsVersion1(t *testing.T) {
+	doc, err := Parse(strings.NewReader(v1Doc))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	feeds := doc.Feeds()
+	if len(feeds) != 1 || feeds[0].URL != "https://example.com/old.rss" || feeds[0].Title != "Old" {
+		t.Errorf("unexpected feeds: %+v", feeds)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse(strings.NewReader("<rss></rss>")); err == nil {
+		t.Error("expected error for non-OPML document, got nil")
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	feeds := []Feed{
+		{Title: "Top & Level", URL: "https://example.com/top.xml?a=1&b=2"},
+		{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", Folders: []string{"Go"}},
+		{Title: "gopls", URL: "https://example.com/gopls.xml", Folders: []string{"Go", "Tools"}},
+		{Title: "Go Weekly", URL: "https://example.com/weekly.xml", Folders: []string{"Go"}},
+	}
+	var buf bytes.Buffer
+	if err := New("Subscriptions", feeds).Write(&buf); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") || !strings.Contains(buf.String(), `<opml version="2.0">`) {
+		t.Errorf("unexpected document header:\n%s", buf.String())
+	}
+	doc, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if doc.Head.Title != "Subscriptions" {
+		t.Errorf("title: got %q, want %q", doc.Head.Title, "Subscriptions")
+	}
+	if n := len(doc.Body.Outlines); n != 2 {
+		t.Errorf("got %d top-level outlines, want 2 (feed and one Go folder)", n)
+	}
+	got := doc.Feeds()
+	want := feeds
+	if len(got) != len(want) {
+		t.Fatalf("got %d feeds, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Title != want[i].Title || got[i].URL != want[i].URL || !reflect.DeepEqual(got[i].Folders, want[i].Folders) {
+			t.Errorf("feed %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
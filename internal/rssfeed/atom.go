@@ -0,0 +1,130 @@
+package rssfeed
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type atomFeed struct {
+	Title    atomText    `xml:"title"`
+	Subtitle atomText    `xml:"subtitle"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title     atomText     `xml:"title"`
+	ID        string       `xml:"id"`
+	Links     []atomLink   `xml:"link"`
+	Published string       `xml:"published"`
+	Updated   string       `xml:"updated"`
+	Summary   atomText     `xml:"summary"`
+	Content   atomText     `xml:"content"`
+	Authors   []atomPerson `xml:"author"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+// atomText is an Atom text construct; xhtml content is kept as markup
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return unwrapXHTMLDiv(strings.TrimSpace(t.Inner))
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// Plain returns the text with any html or xhtml markup removed, for fields
+// such as titles that are shown as a single line
+func (t atomText) Plain() string {
+	if t.Type == "html" || t.Type == "xhtml" {
+		return strings.Join(strings.Fields(PlainText(t.String())), " ")
+	}
+	return t.String()
+}
+
+// unwrapXHTMLDiv strips the div that RFC 4287 requires around xhtml text,
+// which may carry a namespace prefix such as <xhtml:div>
+func unwrapXHTMLDiv(inner string) string {
+	end := strings.IndexAny(inner, " \t\r\n/>")
+	if !strings.HasPrefix(inner, "<") || end < 0 {
+		return inner
+	}
+	name := inner[1:end]
+	if name != "div" && !strings.HasSuffix(name, ":div") {
+		return inner
+	}
+	open := strings.Index(inner, ">")
+	if inner[open-1] == '/' {
+		return ""
+	}
+	body, ok := strings.CutSuffix(inner[open+1:], "</"+name+">")
+	if !ok {
+		return inner
+	}
+	return strings.TrimSpace(body)
+}
+
+// alternateLink returns the entry's rel="alternate" link, falling back to the
+// first link when none is marked as alternate
+func (e atomEntry) alternateLink() string {
+	for _, l := range e.Links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	if len(e.Links) > 0 {
+		return e.Links[0].Href
+	}
+	return ""
+}
+
+func parseAtom(body []byte) (*RSSFeed, error) {
+	var af atomFeed
+	if err := xml.Unmarshal(body, &af); err != nil {
+		return nil, err
+	}
+	feed := &RSSFeed{Channel: RSSChannel{
+		Title:       af.Title.Plain(),
+		Description: af.Subtitle.String(),
+	}}
+	for _, e := range af.Entries {
+		item := RSSItem{
+			Title:       e.Title.Plain(),
+			Description: e.Summary.String(),
+			Link:        e.alternateLink(),
+			Guid:        e.ID,
+			PublishedAt: e.Published,
+		}
+		for _, a := range e.Authors {
+			if a.Name == "" {
+				continue
+			}
+			if item.Author != "" {
+				item.Author += ", "
+			}
+			item.Author += a.Name
+		}
+		if item.Description == "" {
+			item.Description = e.Content.String()
+		}
+		if item.PublishedAt == "" {
+			item.PublishedAt = e.Updated
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return feed, nil
+}
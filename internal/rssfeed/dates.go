@@ -0,0 +1,166 @@
+package rssfeed
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// zonedLayouts carry their own offset; zone abbreviations are rewritten to
+// numeric offsets by normalizeDate before these are tried
+var zonedLayouts = []string{
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 January 2006 15:04:05 -0700",
+	"Jan 2 2006 15:04:05 -0700",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	// Hour-only offsets such as "+01", as PostgreSQL and some generators
+	// print them
+	"2 Jan 2006 15:04:05 -07",
+	"2006-01-02T15:04:05-07",
+	"2006-01-02 15:04:05-07",
+	"2006-01-02 15:04:05 -07",
+}
+
+// localLayouts have no zone and are interpreted in the fallback location
+var localLayouts = []string{
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+	"2 January 2006 15:04:05",
+	"2 January 2006",
+	"Jan 2 15:04:05 2006",
+	"Jan 2 2006",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// zoneOffsets maps the zone names real feeds use to numeric offsets, since
+// time.Parse silently treats unknown abbreviations as UTC. Where a name is
+// ambiguous the most common meaning in feeds wins: CST is US Central and IST
+// is India.
+var zoneOffsets = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"WET":  "+0000",
+	"WEST": "+0100",
+	"BST":  "+0100",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"MSK":  "+0300",
+	"IST":  "+0530",
+	"SGT":  "+0800",
+	"HKT":  "+0800",
+	"AWST": "+0800",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"ACST": "+0930",
+	"ACDT": "+1030",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+	"NST":  "-0330",
+	"NDT":  "-0230",
+	"AST":  "-0400",
+	"ADT":  "-0300",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+}
+
+var weekdayPrefixes = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// ParseDate parses a feed timestamp in any of the RFC 822/1123, RFC 3339 and
+// ISO 8601 variants feeds commonly emit. Timestamps without a zone are
+// interpreted in loc.
+func ParseDate(value string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	normalized := normalizeDate(value)
+	if normalized == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+	for _, layout := range zonedLayouts {
+		if t, err := time.Parse(layout, normalized); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, normalized, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format: %q", value)
+}
+
+// normalizeDate collapses whitespace and strips the parts of a timestamp that
+// vary between feeds without carrying information: weekday names, commas and
+// trailing zone comments such as "(UTC)". Zone abbreviations become offsets.
+func normalizeDate(value string) string {
+	if i := strings.Index(value, "("); i > 0 {
+		value = value[:i]
+	}
+	fields := strings.Fields(strings.ReplaceAll(value, ",", " "))
+	if len(fields) == 0 {
+		return ""
+	}
+	if isWeekday(fields[0]) {
+		fields = fields[1:]
+	}
+	if len(fields) > 1 {
+		last := strings.ToUpper(fields[len(fields)-1])
+		if offset, ok := zoneOffsets[last]; ok {
+			fields[len(fields)-1] = offset
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+func isWeekday(field string) bool {
+	lower := strings.ToLower(strings.TrimSuffix(field, "."))
+	for _, prefix := range weekdayPrefixes {
+		if strings.HasPrefix(lower, prefix) && strings.Trim(lower, "abcdefghijklmnopqrstuvwxyz") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Published returns the item's publication time, trying each date field the
+// supported formats populate. ok is false when none of them can be parsed.
+func (item RSSItem) Published(loc *time.Location) (t time.Time, ok bool) {
+	for _, value := range []string{item.PubDate, item.PublishedAt, item.DCDate} {
+		if value == "" {
+			continue
+		}
+		if t, err := ParseDate(value, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,70 @@
+package rssfeed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"Mon, 02 Jan 2006 15:04:05 +0000", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Mon, 02 Jan 2006 15:04:05 GMT", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Mon, 2 Jan 2006 10:04:05 EST", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"02 Jan 06 15:04 +0000", time.Date(2006, 1, 2, 15, 4, 0, 0, time.UTC)},
+		{"Monday, 02 January 2006 15:04:05 +0000", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"  Tue,  3 Jan 2006   15:04:05  +0000 (UTC) ", time.Date(2006, 1, 3, 15, 4, 5, 0, time.UTC)},
+		{"Mon, 02 Jan 2006 15:04:05 +00:00", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2006-01-02T15:04:05Z", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2006-01-02T16:04:05.123+01:00", time.Date(2006, 1, 2, 15, 4, 5, 123000000, time.UTC)},
+		{"2006-01-02 15:04:05 UTC", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Mon, 02 Jan 2006 16:04:05 CET", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Fri, 02 Jun 2006 17:04:05 CEST", time.Date(2006, 6, 2, 15, 4, 5, 0, time.UTC)},
+		{"Fri, 02 Jun 2006 16:04:05 BST", time.Date(2006, 6, 2, 15, 4, 5, 0, time.UTC)},
+		{"Mon, 02 Jan 2006 20:34:05 IST", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Tue, 03 Jan 2006 01:04:05 AEST", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Tue, 03 Jan 2006 00:04:05 jst", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Mon, 02 Jan 2006 16:04:05 +01", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2006-01-02T16:04:05+01", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2006-01-02 10:04:05.5-05", time.Date(2006, 1, 2, 15, 4, 5, 500000000, time.UTC)},
+		// No zone: interpreted in the fallback location
+		{"2006-01-02T16:04:05", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Mon, 02 Jan 2006 16:04:05", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2006-01-02", time.Date(2006, 1, 1, 23, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseDate(tt.input, berlin)
+		if err != nil {
+			t.Errorf("ParseDate(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseDate(%q) = %v, want %v", tt.input, got.UTC(), tt.want)
+		}
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, input := range []string{"", "   ", "yesterday", "32 Foo 2006"} {
+		if _, err := ParseDate(input, time.UTC); err == nil {
+			t.Errorf("ParseDate(%q): expected error, got nil", input)
+		}
+	}
+}
+
+func TestItemPublished(t *testing.T) {
+	item := RSSItem{PubDate: "not a date", DCDate: "2024-05-06T09:30:00Z"}
+	got, ok := item.Published(time.UTC)
+	if !ok {
+		t.Fatal("Published() returned ok=false, want fallback to dc:date")
+	}
+	if want := time.Date(2024, 5, 6, 9, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Published() = %v, want %v", got, want)
+	}
+	if _, ok := (RSSItem{}).Published(time.UTC); ok {
+		t.Error("Published() on item without dates returned ok=true")
+	}
+}
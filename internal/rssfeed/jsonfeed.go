@@ -0,0 +1,107 @@
+package rssfeed
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            jsonFeedID       `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Author        *jsonFeedAuthor  `json:"author"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// jsonFeedID accepts the numeric ids some publishers emit instead of strings
+type jsonFeedID string
+
+func (id *jsonFeedID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = jsonFeedID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*id = jsonFeedID(n.String())
+	return nil
+}
+
+// isJSONFeed reports whether a response should be parsed as a JSON Feed,
+// based on its content type or, failing that, on the body itself
+func isJSONFeed(contentType string, body []byte) bool {
+	if strings.Contains(contentType, "application/feed+json") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
+
+// authorNames joins the 1.1 authors list, falling back to the 1.0 author field
+func (i jsonFeedItem) authorNames() string {
+	var names []string
+	for _, a := range i.Authors {
+		if a.Name != "" {
+			names = append(names, a.Name)
+		}
+	}
+	if len(names) == 0 && i.Author != nil {
+		return i.Author.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func parseJSONFeed(body []byte) (*RSSFeed, error) {
+	var jf jsonFeed
+	if err := json.Unmarshal(body, &jf); err != nil {
+		return nil, err
+	}
+	feed := &RSSFeed{Channel: RSSChannel{
+		Title:       jf.Title,
+		Description: jf.Description,
+	}}
+	for _, i := range jf.Items {
+		item := RSSItem{
+			Title:       i.Title,
+			Description: i.ContentHTML,
+			Link:        i.URL,
+			Guid:        string(i.ID),
+			PublishedAt: i.DatePublished,
+			Author:      i.authorNames(),
+		}
+		if item.Description == "" {
+			item.Description = i.ContentText
+		}
+		if item.Description == "" {
+			item.Description = i.Summary
+		}
+		if item.Link == "" {
+			item.Link = i.ExternalURL
+		}
+		if item.PublishedAt == "" {
+			item.PublishedAt = i.DateModified
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return feed, nil
+}
@@ -0,0 +1,31 @@
+package rssfeed
+
+import "encoding/xml"
+
+const rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// rdfFeed is an RSS 1.0 document, where items are siblings of the channel
+// rather than children of it
+type rdfFeed struct {
+	Channel RSSChannel `xml:"channel"`
+	Items   []RSSItem  `xml:"item"`
+}
+
+func parseRDF(body []byte) (*RSSFeed, error) {
+	var rf rdfFeed
+	if err := xml.Unmarshal(body, &rf); err != nil {
+		return nil, err
+	}
+	feed := &RSSFeed{Channel: RSSChannel{
+		Title:       rf.Channel.Title,
+		Description: rf.Channel.Description,
+		Items:       rf.Items,
+	}}
+	for i := range feed.Channel.Items {
+		item := &feed.Channel.Items[i]
+		if item.PublishedAt == "" {
+			item.PublishedAt = item.DCDate
+		}
+	}
+	return feed, nil
+}
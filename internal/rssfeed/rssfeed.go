@@ -1,8 +1,11 @@
 package rssfeed
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"html"
 	"io"
 	"net/http"
@@ -25,26 +28,67 @@ type RSSItem struct {
 	Guid        string `xml:"guid"`
 	PublishedAt string `xml:"published"`
 	PubDate     string `xml:"pubDate"`
+	Author      string `xml:"author"`
+	DCDate      string `xml:"http://purl.org/dc/elements/1.1/ date"`
 }
 
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+// ErrNotModified is returned by FetchFeed when the server answers a
+// conditional request with 304 Not Modified
+var ErrNotModified = errors.New("feed not modified")
+
+// CacheValidators holds the ETag and Last-Modified response headers of the
+// previous fetch, sent back as If-None-Match and If-Modified-Since
+type CacheValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// FetchFeed downloads and parses a feed. It returns the validators of the
+// response so the caller can make the next request conditional.
+func FetchFeed(ctx context.Context, feedURL string, cache CacheValidators) (*RSSFeed, CacheValidators, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, cache, err
 	}
 	req.Header.Set("User-Agent", "gator")
+	if cache.ETag != "" {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
+	if cache.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cache.LastModified)
+	}
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, cache, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, cache, ErrNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, cache, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, cache, err
 	}
-	var feed RSSFeed
-	if err := xml.Unmarshal(body, &feed); err != nil {
+	feed, err := ParseFeed(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, cache, err
+	}
+	validators := CacheValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	return feed, validators, nil
+}
+
+// ParseFeed detects the format of a feed document from its content type or
+// root element and parses it into the common RSSFeed model
+func ParseFeed(body []byte, contentType string) (*RSSFeed, error) {
+	feed, err := parseAnyFeed(body, contentType)
+	if err != nil {
 		return nil, err
 	}
 	// Unescape channel fields
@@ -54,5 +98,47 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 		feed.Channel.Items[i].Title = html.UnescapeString(feed.Channel.Items[i].Title)
 		feed.Channel.Items[i].Description = html.UnescapeString(feed.Channel.Items[i].Description)
 	}
+	return feed, nil
+}
+
+func parseAnyFeed(body []byte, contentType string) (*RSSFeed, error) {
+	if isJSONFeed(contentType, body) {
+		return parseJSONFeed(body)
+	}
+	root, err := rootElement(body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case root.Local == "rss":
+		return parseRSS(body)
+	case root.Local == "feed" && (root.Space == atomNamespace || root.Space == ""):
+		return parseAtom(body)
+	case root.Local == "RDF" && root.Space == rdfNamespace:
+		return parseRDF(body)
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", root.Local)
+	}
+}
+
+// rootElement returns the name of the first element in an XML document
+func rootElement(body []byte) (xml.Name, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, fmt.Errorf("could not find root element: %v", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
+
+func parseRSS(body []byte) (*RSSFeed, error) {
+	var feed RSSFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, err
+	}
 	return &feed, nil
 }
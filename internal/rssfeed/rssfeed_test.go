@@ -0,0 +1,288 @@
+package rssfeed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const rssDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+  <title>RSS Blog</title>
+  <description>An RSS feed</description>
+  <item>
+    <title>First &amp;amp; Foremost</title>
+    <link>https://example.com/first</link>
+    <guid>first</guid>
+    <pubDate>Mon, 02 Jan 2006 15:04:05 +0000</pubDate>
+    <description>Hello</description>
+  </item>
+</channel>
+</rss>`
+
+const atomDoc = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Atom Blog</title>
+  <subtitle>An Atom feed</subtitle>
+  <entry>
+    <title>Release v1.0</title>
+    <link rel="self" href="https://example.com/self"/>
+    <link rel="alternate" href="https://example.com/releases/v1.0"/>
+    <id>tag:example.com,2024:release-1</id>
+    <updated>2024-03-01T10:00:00Z</updated>
+    <content type="html">&lt;p&gt;Notes&lt;/p&gt;</content>
+  </entry>
+  <entry>
+    <title>Post</title>
+    <link href="https://example.com/post"/>
+    <id>tag:example.com,2024:post</id>
+    <published>2024-02-01T10:00:00Z</published>
+    <updated>2024-02-02T10:00:00Z</updated>
+    <summary>Short summary</summary>
+    <content type="html">Long content</content>
+  </entry>
+</feed>`
+
+func TestParseFeedRSS(t *testing.T) {
+	feed, err := ParseFeed([]byte(rssDoc), "application/rss+xml")
+	if err != nil {
+		t.Fatalf("ParseFeed() error: %v", err)
+	}
+	if feed.Channel.Title != "RSS Blog" {
+		t.Errorf("channel title: got %q, want %q", feed.Channel.Title, "RSS Blog")
+	}
+	if len(feed.Channel.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Channel.Items))
+	}
+	item := feed.Channel.Items[0]
+	if item.Title != "First & Foremost" {
+		t.Errorf("item title: got %q, want %q", item.Title, "First & Foremost")
+	}
+	if item.Link != "https://example.com/first" || item.Guid != "first" {
+		t.Errorf("unexpected link/guid: %+v", item)
+	}
+}
+
+func TestParseFeedAtom(t *testing.T) {
+	feed, err := ParseFeed([]byte(atomDoc), "application/atom+xml")
+	if err != nil {
+		t.Fatalf("ParseFeed() error: %v", err)
+	}
+	if feed.Channel.Title != "Atom Blog" || feed.Channel.Description != "An Atom feed" {
+		t.Errorf("unexpected channel: %+v", feed.Channel)
+	}
+	if len(feed.Channel.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(feed.Channel.Items))
+	}
+	first := feed.Channel.Items[0]
+	if first.Link != "https://example.com/releases/v1.0" {
+		t.Errorf("link: got %q, want alternate link", first.Link)
+	}
+	if first.Guid != "tag:example.com,2024:release-1" {
+		t.Errorf("guid: got %q", first.Guid)
+	}
+	if first.Description != "<p>Notes</p>" {
+		t.Errorf("description: got %q, want content fallback", first.Description)
+	}
+	if first.PublishedAt != "2024-03-01T10:00:00Z" {
+		t.Errorf("published: got %q, want updated fallback", first.PublishedAt)
+	}
+	second := feed.Channel.Items[1]
+	if second.Link != "https://example.com/post" {
+		t.Errorf("link: got %q", second.Link)
+	}
+	if second.Description != "Short summary" {
+		t.Errorf("description: got %q, want summary", second.Description)
+	}
+	if second.PublishedAt != "2024-02-01T10:00:00Z" {
+		t.Errorf("published: got %q", second.PublishedAt)
+	}
+}
+
+const atomXHTMLDoc = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="html">Tom &amp;amp; &lt;em&gt;Jerry&lt;/em&gt;</title>
+  <entry>
+    <title type="xhtml">
+      <div xmlns="http://www.w3.org/1999/xhtml">Less <b>is</b>
+        more &amp; better</div>
+    </title>
+    <link href="https://example.com/less"/>
+    <id>tag:example.com,2024:less</id>
+    <updated>2024-03-01T10:00:00Z</updated>
+    <content type="xhtml"><xhtml:div xmlns:xhtml="http://www.w3.org/1999/xhtml"><xhtml:p>Body</xhtml:p></xhtml:div></content>
+  </entry>
+</feed>`
+
+func TestParseFeedAtomXHTML(t *testing.T) {
+	feed, err := ParseFeed([]byte(atomXHTMLDoc), "application/atom+xml")
+	if err != nil {
+		t.Fatalf("ParseFeed() error: %v", err)
+	}
+	if feed.Channel.Title != "Tom & Jerry" {
+		t.Errorf("channel title: got %q, want %q", feed.Channel.Title, "Tom & Jerry")
+	}
+	if len(feed.Channel.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Channel.Items))
+	}
+	item := feed.Channel.Items[0]
+	if item.Title != "Less is more & better" {
+		t.Errorf("title: got %q, want %q", item.Title, "Less is more & better")
+	}
+	if item.Description != "<xhtml:p>Body</xhtml:p>" {
+		t.Errorf("description: got %q, want content without the wrapper div", item.Description)
+	}
+}
+
+func TestParseFeedUnsupported(t *testing.T) {
+	if _, err := ParseFeed([]byte(`<html><body></body></html>`), "text/html"); err == nil {
+		t.Error("expected error for unsupported document, got nil")
+	}
+}
+
+const jsonFeedDoc = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "JSON Blog",
+  "description": "A JSON feed",
+  "items": [
+    {
+      "id": "1",
+      "url": "https://example.com/one",
+      "title": "One",
+      "content_html": "<p>One</p>",
+      "date_published": "2024-01-01T00:00:00Z",
+      "authors": [{"name": "Ann"}, {"name": "Bob"}]
+    },
+    {
+      "id": 2,
+      "external_url": "https://other.example.com/two",
+      "title": "Two",
+      "content_text": "Two as text",
+      "author": {"name": "Cy"}
+    }
+  ]
+}`
+
+func TestParseFeedJSON(t *testing.T) {
+	// Sniffed from the body even without a JSON Feed content type
+	for _, contentType := range []string{"application/feed+json", "text/plain"} {
+		feed, err := ParseFeed([]byte(jsonFeedDoc), contentType)
+		if err != nil {
+			t.Fatalf("ParseFeed(%q) error: %v", contentType, err)
+		}
+		if feed.Channel.Title != "JSON Blog" {
+			t.Errorf("channel title: got %q, want %q", feed.Channel.Title, "JSON Blog")
+		}
+		if len(feed.Channel.Items) != 2 {
+			t.Fatalf("got %d items, want 2", len(feed.Channel.Items))
+		}
+		one, two := feed.Channel.Items[0], feed.Channel.Items[1]
+		if one.Guid != "1" || one.Link != "https://example.com/one" || one.Description != "<p>One</p>" {
+			t.Errorf("unexpected first item: %+v", one)
+		}
+		if one.PublishedAt != "2024-01-01T00:00:00Z" || one.Author != "Ann, Bob" {
+			t.Errorf("unexpected first item date/author: %+v", one)
+		}
+		if two.Guid != "2" || two.Link != "https://other.example.com/two" || two.Description != "Two as text" {
+			t.Errorf("unexpected second item: %+v", two)
+		}
+		if two.Author != "Cy" {
+			t.Errorf("author: got %q, want %q", two.Author, "Cy")
+		}
+	}
+}
+
+const rdfDoc = `<?xml version="1.0" encoding="utf-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+  xmlns:dc="http://purl.org/dc/elements/1.1/"
+  xmlns="http://purl.org/rss/1.0/">
+  <channel rdf:about="https://example.edu/">
+    <title>Department News</title>
+    <description>An RSS 1.0 feed</description>
+    <items>
+      <rdf:Seq>
+        <rdf:li rdf:resource="https://example.edu/news/1"/>
+      </rdf:Seq>
+    </items>
+  </channel>
+  <item rdf:about="https://example.edu/news/1">
+    <title>Seminar</title>
+    <link>https://example.edu/news/1</link>
+    <description>Talk on Friday</description>
+    <dc:date>2024-05-06T09:30:00+02:00</dc:date>
+  </item>
+</rdf:RDF>`
+
+func TestParseFeedRDF(t *testing.T) {
+	feed, err := ParseFeed([]byte(rdfDoc), "application/rdf+xml")
+	if err != nil {
+		t.Fatalf("ParseFeed() error: %v", err)
+	}
+	if feed.Channel.Title != "Department News" {
+		t.Errorf("channel title: got %q, want %q", feed.Channel.Title, "Department News")
+	}
+	if len(feed.Channel.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Channel.Items))
+	}
+	item := feed.Channel.Items[0]
+	if item.Title != "Seminar" || item.Link != "https://example.edu/news/1" {
+		t.Errorf("unexpected item: %+v", item)
+	}
+	if item.PublishedAt != "2024-05-06T09:30:00+02:00" {
+		t.Errorf("published: got %q, want dc:date", item.PublishedAt)
+	}
+}
+
+func TestFetchFeedConditional(t *testing.T) {
+	const etag = `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Write([]byte(rssDoc))
+	}))
+	defer server.Close()
+
+	feed, validators, err := FetchFeed(context.Background(), server.URL, CacheValidators{})
+	if err != nil {
+		t.Fatalf("FetchFeed() error: %v", err)
+	}
+	if len(feed.Channel.Items) != 1 {
+		t.Errorf("got %d items, want 1", len(feed.Channel.Items))
+	}
+	if validators.ETag != etag || validators.LastModified == "" {
+		t.Errorf("unexpected validators: %+v", validators)
+	}
+
+	_, again, err := FetchFeed(context.Background(), server.URL, validators)
+	if !errors.Is(err, ErrNotModified) {
+		t.Fatalf("FetchFeed() with validators: got error %v, want ErrNotModified", err)
+	}
+	if again != validators {
+		t.Errorf("validators changed on 304: got %+v, want %+v", again, validators)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"plain", "plain"},
+		{"<p>One &amp; <b>two</b></p><p>Three</p>", "One & two\n\nThree"},
+		{"line<br/>break", "line\nbreak"},
+		{"<p>a</p>\n\n\n<p>b</p>", "a\n\nb"},
+		{"  <div>  spaced   out </div> ", "spaced out"},
+	}
+	for _, tt := range tests {
+		if got := PlainText(tt.input); got != tt.want {
+			t.Errorf("PlainText(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package rssfeed
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	paragraphPattern = regexp.MustCompile(`(?i)<\s*/(p|div|h[1-6]|blockquote|ul|ol)\s*>`)
+	lineBreakPattern = regexp.MustCompile(`(?i)<\s*(br|/li|/tr)\s*/?>`)
+	tagPattern       = regexp.MustCompile(`<[^>]*>`)
+	blankPattern     = regexp.MustCompile(`[ \t]+`)
+	newlinePattern   = regexp.MustCompile(`[ \t]*\n[ \t]*`)
+	paragraphsGap    = regexp.MustCompile(`\n{3,}`)
+)
+
+// PlainText converts an HTML item description to plain text for display in
+// a terminal, keeping paragraph breaks
+func PlainText(s string) string {
+	s = paragraphPattern.ReplaceAllString(s, "\n\n")
+	s = lineBreakPattern.ReplaceAllString(s, "\n")
+	s = tagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = blankPattern.ReplaceAllString(s, " ")
+	s = newlinePattern.ReplaceAllString(s, "\n")
+	s = paragraphsGap.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
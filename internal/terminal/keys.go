@@ -0,0 +1,70 @@
+package terminal
+
+import "unicode/utf8"
+
+// Key is a key press read from the terminal. Special keys use the constants
+// below; other keys are the rune typed.
+type Key rune
+
+const (
+	KeyUnknown Key = -1 - iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+)
+
+const (
+	KeyTab    Key = '\t'
+	KeyEnter  Key = '\r'
+	KeyEscape Key = 0x1b
+	KeyCtrlC  Key = 0x03
+)
+
+// ReadKey blocks until a key is pressed and returns it
+func (t *Terminal) ReadKey() (Key, error) {
+	buf := make([]byte, 16)
+	n, err := t.tty.Read(buf)
+	if err != nil {
+		return KeyUnknown, err
+	}
+	return decodeKey(buf[:n]), nil
+}
+
+// escapeKeys maps the escape sequences sent by common terminals to keys
+var escapeKeys = map[string]Key{
+	"\x1b[A": KeyUp, "\x1bOA": KeyUp,
+	"\x1b[B": KeyDown, "\x1bOB": KeyDown,
+	"\x1b[C": KeyRight, "\x1bOC": KeyRight,
+	"\x1b[D": KeyLeft, "\x1bOD": KeyLeft,
+	"\x1b[5~": KeyPageUp, "\x1b[6~": KeyPageDown,
+	"\x1b[H": KeyHome, "\x1b[1~": KeyHome, "\x1bOH": KeyHome,
+	"\x1b[F": KeyEnd, "\x1b[4~": KeyEnd, "\x1bOF": KeyEnd,
+}
+
+func decodeKey(b []byte) Key {
+	if len(b) == 0 {
+		return KeyUnknown
+	}
+	if b[0] == 0x1b {
+		if len(b) == 1 {
+			return KeyEscape
+		}
+		if k, ok := escapeKeys[string(b)]; ok {
+			return k
+		}
+		return KeyUnknown
+	}
+	if b[0] == '\n' {
+		return KeyEnter
+	}
+	r, _ := utf8.DecodeRune(b)
+	if r == utf8.RuneError {
+		return KeyUnknown
+	}
+	return Key(r)
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package terminal
+
+import "errors"
+
+var errUnsupported = errors.New("interactive mode is not supported on this platform")
+
+func makeRaw(fd uintptr) (func() error, error) {
+	return nil, errUnsupported
+}
+
+func size(fd uintptr) (int, int, error) {
+	return 0, 0, errUnsupported
+}
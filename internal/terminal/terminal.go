@@ -0,0 +1,150 @@
+// Package terminal provides the small amount of terminal handling the
+// interactive reader needs: raw mode, the alternate screen, key decoding and
+// text layout helpers.
+package terminal
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Terminal is an interactive terminal in raw mode showing the alternate
+// screen. Drawing is buffered until Flush.
+type Terminal struct {
+	tty     *os.File
+	out     *bufio.Writer
+	restore func() error
+}
+
+// Open puts the controlling terminal into raw mode and switches to the
+// alternate screen. Close must be called to restore it.
+func Open() (*Terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no terminal available: %v", err)
+	}
+	restore, err := makeRaw(tty.Fd())
+	if err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("failed to enter raw mode: %v", err)
+	}
+	t := &Terminal{tty: tty, out: bufio.NewWriter(tty), restore: restore}
+	// Alternate screen, hidden cursor
+	t.out.WriteString("\x1b[?1049h\x1b[?25l")
+	return t, t.Flush()
+}
+
+// Close leaves the alternate screen and restores the terminal's mode
+func (t *Terminal) Close() error {
+	t.out.WriteString("\x1b[0m\x1b[?25h\x1b[?1049l")
+	t.Flush()
+	err := t.restore()
+	t.tty.Close()
+	return err
+}
+
+// Size returns the terminal's width and height in cells
+func (t *Terminal) Size() (int, int, error) {
+	return size(t.tty.Fd())
+}
+
+// Clear erases the screen
+func (t *Terminal) Clear() {
+	t.out.WriteString("\x1b[2J")
+}
+
+// Print writes text at the given zero-based column and row. The text must
+// already fit on the line.
+func (t *Terminal) Print(x, y int, text string) {
+	fmt.Fprintf(t.out, "\x1b[%d;%dH%s", y+1, x+1, text)
+}
+
+// PrintStyled writes text like Print, wrapped in the given SGR style (e.g.
+// Reverse or Bold)
+func (t *Terminal) PrintStyled(x, y int, style, text string) {
+	fmt.Fprintf(t.out, "\x1b[%d;%dH\x1b[%sm%s\x1b[0m", y+1, x+1, style, text)
+}
+
+// SGR styles for PrintStyled
+const (
+	Bold    = "1"
+	Dim     = "2"
+	Reverse = "7"
+)
+
+// Copy asks the terminal to put text on the system clipboard using the OSC 52
+// escape sequence. Terminals that don't support it ignore the request.
+func (t *Terminal) Copy(text string) {
+	fmt.Fprintf(t.out, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+}
+
+// Flush sends buffered output to the terminal
+func (t *Terminal) Flush() error {
+	return t.out.Flush()
+}
+
+// Fit truncates s to width cells, marking truncation with an ellipsis, and
+// pads it with spaces to exactly width cells. Every rune is treated as one
+// cell wide.
+func Fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		runes := []rune(s)
+		return string(runes[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+// Wrap breaks text into lines of at most width runes, wrapping at spaces
+// where possible and keeping blank lines between paragraphs
+func Wrap(text string, width int) []string {
+	if width <= 0 {
+		return nil
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := ""
+		for _, word := range words {
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:width]))
+				word = string(runes[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
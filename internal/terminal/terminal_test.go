@@ -0,0 +1,60 @@
+package terminal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"abc", 5, "abc  "},
+		{"abcdef", 4, "abc…"},
+		{"héllo", 5, "héllo"},
+		{"a\tb", 3, "a b"},
+		{"abc", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := Fit(tt.in, tt.width); got != tt.want {
+			t.Errorf("Fit(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	got := Wrap("the quick brown fox\n\njumps over", 10)
+	want := []string{"the quick", "brown fox", "", "jumps over"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Wrap() = %q, want %q", got, want)
+	}
+	got = Wrap("abcdefghij klm", 4)
+	want = []string{"abcd", "efgh", "ij", "klm"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Wrap() long word = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Key
+	}{
+		{"j", 'j'},
+		{"\x1b[A", KeyUp},
+		{"\x1bOB", KeyDown},
+		{"\x1b[6~", KeyPageDown},
+		{"\x1b", KeyEscape},
+		{"\r", KeyEnter},
+		{"\n", KeyEnter},
+		{"\x1b[99~", KeyUnknown},
+		{"é", 'é'},
+	}
+	for _, tt := range tests {
+		if got := decodeKey([]byte(tt.in)); got != tt.want {
+			t.Errorf("decodeKey(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
@@ -12,31 +12,48 @@ import (
 )
 
 func main() {
+	commandSet := cmds.DefaultCommands()
+
+	output, args, err := cmds.ExtractOutputFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) < 1 {
+		commandSet.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	cmdName := args[0]
+	if cmdName == "-h" || cmdName == "--help" {
+		cmdName = "help"
+	}
+	cmdArgs := args[1:]
+	cmd := cmds.Command{Name: cmdName, Args: cmdArgs}
+
 	cfg, err := config.Read()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	db, err := sql.Open("postgres", cfg.DBUrl)
+	dsn, err := cfg.DSN()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading db_url: %v\n", err)
 		os.Exit(1)
 	}
 
-	dbQueries := database.New(db)
-
-	state := &cmds.State{Db: dbQueries, Cfg: &cfg}
-	commandSet := cmds.DefaultCommands()
-
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Not enough arguments. Usage: gator <command> [args...]\n")
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
 		os.Exit(1)
 	}
 
-	cmdName := os.Args[1]
-	cmdArgs := os.Args[2:]
-	cmd := cmds.Command{Name: cmdName, Args: cmdArgs}
+	dbQueries := database.New(db)
+
+	state := &cmds.State{Conn: db, Db: dbQueries, Cfg: &cfg, Output: output}
+	state.Migrate = func() error { return cmds.MigrateDatabase(state) }
 
 	err = commandSet.Run(state, cmd)
 	if err != nil {
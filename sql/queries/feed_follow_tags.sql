@@ -0,0 +1,14 @@
+-- name: TagFeedFollow :exec
+INSERT INTO feed_follow_tags (feed_follow_id, tag, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (feed_follow_id, tag) DO NOTHING;
+
+-- name: UntagFeedFollow :execrows
+DELETE FROM feed_follow_tags WHERE feed_follow_id = $1 AND tag = $2;
+
+-- name: GetFeedFollowTagsForUser :many
+SELECT ff.feed_id, fft.tag
+FROM feed_follow_tags fft
+INNER JOIN feed_follows ff ON fft.feed_follow_id = ff.id
+WHERE ff.user_id = $1
+ORDER BY fft.tag;
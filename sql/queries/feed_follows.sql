@@ -23,7 +23,9 @@ SELECT
     ff.updated_at,
     ff.user_id,
     ff.feed_id,
-    f.name AS feed_name,
+    ff.title,
+    feed_display_name(ff.title, f.name, f.url, f.channel_title) AS feed_name,
+    f.url AS feed_url,
     u.name AS user_name
 FROM feed_follows ff
 INNER JOIN feeds f ON ff.feed_id = f.id
@@ -33,3 +35,24 @@ ORDER BY ff.created_at DESC;
 
 -- name: DeleteFeedFollowByUserAndUrl :exec
 DELETE FROM feed_follows WHERE user_id = $1 AND feed_id = $2;
+
+-- name: GetFollowedFeedsWithUnreadCounts :many
+SELECT
+    f.id,
+    feed_display_name(ff.title, f.name, f.url, f.channel_title) AS name,
+    f.url,
+    COUNT(p.id) FILTER (WHERE pr.post_id IS NULL) AS unread_count
+FROM feed_follows ff
+INNER JOIN feeds f ON ff.feed_id = f.id
+LEFT JOIN posts p ON p.feed_id = f.id
+LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = ff.user_id
+WHERE ff.user_id = $1
+GROUP BY f.id, ff.id
+ORDER BY name;
+
+-- name: GetFeedFollow :one
+SELECT * FROM feed_follows WHERE user_id = $1 AND feed_id = $2;
+
+-- name: UpdateFeedFollowTitle :execrows
+UPDATE feed_follows SET title = $3, updated_at = NOW()
+WHERE user_id = $1 AND feed_id = $2;
@@ -4,18 +4,121 @@ VALUES (gen_random_uuid(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3)
 RETURNING *;
 
 -- name: GetFeedsWithUser :many
-SELECT feeds.name, feeds.url, users.name AS user_name FROM feeds
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, users.name AS user_name,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id) AS follower_count,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = feeds.id) AS post_count
+FROM feeds
 INNER JOIN users ON feeds.user_id = users.id;
 
+-- name: GetFeedDetails :one
+SELECT feeds.*, users.name AS user_name,
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = feeds.id) AS follower_count,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = feeds.id) AS post_count
+FROM feeds
+INNER JOIN users ON feeds.user_id = users.id
+WHERE feeds.url = $1;
+
 -- name: GetFeedByUrl :one
 SELECT * FROM feeds WHERE url = $1;
 
 -- name: MarkFeedFetched :exec
 UPDATE feeds
-SET last_fetched_at = NOW(), updated_at = NOW()
+SET last_fetched_at = NOW(), updated_at = NOW(), lease_expires_at = NULL
+WHERE id = $1;
+
+-- name: ClaimFeedsToFetch :many
+-- Leases the least recently fetched due feeds that no other aggregator holds.
+-- SKIP LOCKED lets concurrent claims pass over each other's rows, and the
+-- lease keeps a claimed feed out of rotation until MarkFeedFetched releases
+-- it or it expires after a crash.
+UPDATE feeds
+SET lease_expires_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE (lease_expires_at IS NULL OR lease_expires_at < NOW())
+        AND disabled_at IS NULL
+        AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+    ORDER BY last_fetched_at ASC NULLS FIRST
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateFeedCacheValidators :exec
+UPDATE feeds
+SET etag = $2, last_modified = $3, updated_at = NOW()
+WHERE id = $1;
+
+
+-- name: ClaimFeed :one
+UPDATE feeds
+SET lease_expires_at = NOW() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id = sqlc.arg(id) AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+RETURNING *;
+
+-- name: GetAllFeeds :many
+-- Every feed, including ones backing off or disabled, in fetch order.
+SELECT * FROM feeds
+ORDER BY last_fetched_at ASC NULLS FIRST;
+
+-- name: GetDueFeeds :many
+SELECT * FROM feeds
+WHERE disabled_at IS NULL AND (next_fetch_at IS NULL OR next_fetch_at <= NOW())
+ORDER BY last_fetched_at ASC NULLS FIRST;
+
+-- name: ReleaseFeedLease :exec
+UPDATE feeds SET lease_expires_at = NULL WHERE id = $1;
+
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET failure_count = 0, last_error = NULL, last_success_at = NOW(), next_fetch_at = NULL, disabled_at = NULL, updated_at = NOW()
 WHERE id = $1;
 
--- name: GetNextFeedToFetch :one
+-- name: RecordFeedFailure :exec
+-- The retry time is computed from the database clock, like leases, so it
+-- compares correctly against NOW() whatever the aggregator's time zone. A
+-- disabled feed stays disabled, since the time it was first disabled, until
+-- it is fetched successfully.
+UPDATE feeds
+SET failure_count = sqlc.arg(failure_count), last_error = sqlc.arg(last_error),
+    next_fetch_at = NOW() + make_interval(secs => sqlc.arg(backoff_seconds)::int),
+    disabled_at = CASE WHEN sqlc.arg(disable)::bool THEN COALESCE(disabled_at, NOW()) ELSE disabled_at END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: GetFeedsByStatus :many
 SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
\ No newline at end of file
+ORDER BY disabled_at IS NULL, failure_count DESC, name;
+
+-- name: UpdateFeedChannelTitle :exec
+UPDATE feeds SET channel_title = $2 WHERE id = $1;
+
+-- name: UpdateFeedName :exec
+UPDATE feeds SET name = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: UpdateFeedUrl :exec
+-- Moving a feed resets its cache validators and failure state, so the new
+-- URL is fetched in full on the next run.
+UPDATE feeds
+SET url = $2, etag = NULL, last_modified = NULL, failure_count = 0, last_error = NULL,
+    next_fetch_at = NULL, disabled_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds WHERE id = $1;
+
+-- name: DeleteFeeds :exec
+DELETE FROM feeds;
+
+-- name: ResetFeedFetchState :exec
+-- Forgets when and how every feed was last fetched, so the next run fetches
+-- each one in full instead of getting "not modified" responses.
+UPDATE feeds
+SET last_fetched_at = NULL, etag = NULL, last_modified = NULL, updated_at = NOW();
+
+-- name: CountFeedsByUser :one
+SELECT COUNT(*) FROM feeds WHERE user_id = $1;
+
+-- name: TransferFeeds :execrows
+UPDATE feeds SET user_id = sqlc.arg(to_user_id), updated_at = NOW()
+WHERE user_id = sqlc.arg(from_user_id);
@@ -0,0 +1,17 @@
+-- name: StarPost :exec
+INSERT INTO post_stars (user_id, post_id, starred_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: UnstarPost :execrows
+DELETE FROM post_stars WHERE user_id = $1 AND post_id = $2;
+
+-- name: GetStarredPostsForUser :many
+SELECT p.*, feed_display_name(ff.title, f.name, f.url, f.channel_title) AS feed_name, ps.starred_at
+FROM post_stars ps
+JOIN posts p ON ps.post_id = p.id
+JOIN feeds f ON p.feed_id = f.id
+LEFT JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = ps.user_id
+WHERE ps.user_id = $1
+ORDER BY ps.starred_at DESC
+LIMIT $2;
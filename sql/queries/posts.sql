@@ -12,11 +12,50 @@ INSERT INTO posts (
     $1, $2, $3, $4, $5, $6, $7, $8
 ) RETURNING *;
 
+-- name: GetPostByID :one
+SELECT * FROM posts WHERE id = $1;
+
+-- name: GetPostByUrl :one
+SELECT * FROM posts WHERE url = $1;
+
 -- name: GetPostsForUser :many
-SELECT p.*
+SELECT p.*, feed_display_name(ff.title, f.name, f.url, f.channel_title) AS feed_name, pr.read_at
 FROM posts p
-JOIN feeds f 
-    ON p.feed_id = f.id
-WHERE f.user_id = $1
-ORDER BY p.published_at DESC
-LIMIT $2;
+JOIN feeds f ON p.feed_id = f.id
+JOIN feed_follows ff ON p.feed_id = ff.feed_id
+LEFT JOIN post_reads pr ON pr.post_id = p.id AND pr.user_id = ff.user_id
+WHERE ff.user_id = sqlc.arg(user_id)
+    AND (sqlc.arg(include_read)::bool OR pr.read_at IS NULL)
+    AND (sqlc.narg(feed_id)::uuid IS NULL OR p.feed_id = sqlc.narg(feed_id))
+    AND (sqlc.narg(since)::timestamp IS NULL OR COALESCE(p.published_at, p.created_at) >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamp IS NULL OR COALESCE(p.published_at, p.created_at) < sqlc.narg(until))
+    AND (sqlc.narg(tag)::text IS NULL OR EXISTS (
+        SELECT 1 FROM feed_follow_tags fft WHERE fft.feed_follow_id = ff.id AND fft.tag = sqlc.narg(tag)
+    ))
+ORDER BY COALESCE(p.published_at, p.created_at) DESC
+LIMIT sqlc.arg(page_size) OFFSET sqlc.arg(page_offset);
+
+-- name: SearchPosts :many
+-- The tsvector expression must match posts_search_idx for the index to be used
+SELECT
+    p.id,
+    p.title,
+    p.url,
+    p.published_at,
+    feed_display_name(ff.title, f.name, f.url, f.channel_title) AS feed_name,
+    ts_rank(to_tsvector('english', p.title || ' ' || COALESCE(p.description, '')), tsq)::real AS rank,
+    ts_headline('english', p.title || ' ' || COALESCE(p.description, ''), tsq, 'StartSel=**, StopSel=**, MaxWords=30, MinWords=10')::text AS snippet
+FROM posts p
+JOIN feeds f ON p.feed_id = f.id
+CROSS JOIN websearch_to_tsquery('english', sqlc.arg(search)) tsq
+LEFT JOIN feed_follows ff ON ff.feed_id = p.feed_id AND ff.user_id = sqlc.arg(user_id)
+WHERE to_tsvector('english', p.title || ' ' || COALESCE(p.description, '')) @@ tsq
+    AND (sqlc.arg(all_feeds)::bool OR ff.id IS NOT NULL)
+    AND (sqlc.narg(feed_id)::uuid IS NULL OR p.feed_id = sqlc.narg(feed_id))
+    AND (sqlc.narg(since)::timestamp IS NULL OR COALESCE(p.published_at, p.created_at) >= sqlc.narg(since))
+ORDER BY rank DESC, COALESCE(p.published_at, p.created_at) DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: DeleteUnstarredPosts :exec
+-- Starred posts are kept: a reading list is exempt from any cleanup.
+DELETE FROM posts WHERE id NOT IN (SELECT post_id FROM post_stars);
@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE posts ALTER COLUMN published_at DROP NOT NULL;
+
+-- +goose Down
+UPDATE posts SET published_at = created_at WHERE published_at IS NULL;
+ALTER TABLE posts ALTER COLUMN published_at SET NOT NULL;
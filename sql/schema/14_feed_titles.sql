@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN channel_title TEXT;
+ALTER TABLE feed_follows ADD COLUMN title TEXT;
+
+-- +goose Down
+ALTER TABLE feed_follows DROP COLUMN title;
+ALTER TABLE feeds DROP COLUMN channel_title;
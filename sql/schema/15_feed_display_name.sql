@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The name a follower sees for a feed: their own title, else the channel
+-- title when the feed was added without a real name, else the feed's name
+CREATE FUNCTION feed_display_name(follow_title TEXT, feed_name TEXT, feed_url TEXT, channel_title TEXT)
+RETURNS TEXT LANGUAGE SQL IMMUTABLE AS $$
+    SELECT COALESCE(follow_title, CASE WHEN feed_name IN ('', feed_url) THEN channel_title END, feed_name)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION feed_display_name(TEXT, TEXT, TEXT, TEXT);
@@ -0,0 +1,10 @@
+// Package schema embeds the goose-format migrations in this directory so the
+// binary can apply them itself.
+package schema
+
+import "embed"
+
+// FS holds every migration file, named NN_description.sql
+//
+//go:embed *.sql
+var FS embed.FS